@@ -15,11 +15,19 @@
 package main
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/banzaicloud/telescopes/internal/platform/log"
 	"github.com/banzaicloud/telescopes/internal/platform/metrics"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/banzaicloud/telescopes/pkg/recommender/productcache"
+	"github.com/banzaicloud/telescopes/pkg/recommender/providerhealth"
+	"github.com/banzaicloud/telescopes/pkg/recommender/spotadvisor"
+	"github.com/banzaicloud/telescopes/pkg/recommender/throttle"
 	"github.com/goph/emperror"
+	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
@@ -44,7 +52,25 @@ func defineFlags(pf *pflag.FlagSet) {
 	pf.String(logLevelFlag, "info", "log level")
 	pf.String(logFormatFlag, "", "log format")
 	pf.String(listenAddressFlag, ":9090", "the address where the server listens to HTTP requests.")
+	pf.Duration(shutdownTimeoutFlag, 30*time.Second, "the time the in-flight requests are allowed to complete in on shutdown")
 	pf.String(cloudInfoFlag, "http://localhost:9090/api/v1", "the address of the Cloud Info service to retrieve attribute and pricing info [format=scheme://host:port/basepath]")
+	pf.Duration(cloudInfoTimeoutFlag, 10*time.Second, "timeout of the calls to the Cloud Info service")
+	pf.Int(maxConcurrencyFlag, throttle.DefaultMaxConcurrency, "the maximum number of concurrent calls to the Cloud Info service, the calls over it wait for a free slot, unlimited if 0")
+	pf.Duration(maxWaitFlag, throttle.DefaultMaxWait, "the maximum time a call to the Cloud Info service waits for a free slot before it fails, unlimited if 0")
+	pf.Duration(productsCacheTtlFlag, productcache.DefaultTtl, "the time the product details retrieved from the Cloud Info service are cached for, disabled if 0")
+	pf.StringSlice(prewarmRegionsFlag, nil, "the regions the product details are refreshed for in the background, so the recommendations are served from the cache [format=amazon/compute/eu-west-1]")
+	pf.Duration(prewarmIntervalFlag, 4*time.Minute, "the interval the product details of the prewarm regions are refreshed at, it should be shorter than the products cache ttl")
+	pf.String(spotAdvisorUrlFlag, spotadvisor.DefaultUrl, "the address of the AWS Spot Instance Advisor dataset used to rank instance types by interruption frequency, disabled if empty")
+	pf.StringSlice(currencyRatesFlag, nil, "conversion rates of the USD prices to other currencies [format=EUR=0.88,GBP=0.77]")
+	pf.Float64(hoursPerMonthFlag, recommender.DefaultHoursPerMonth, "the number of hours the monthly costs of the node pools are estimated for")
+	pf.StringSlice(defaultZonesFlag, nil, "the zones of a region the recommendations are restricted to if the request has no zones, all zones are used by default [format=eu-west-1=eu-west-1a:eu-west-1b]")
+	pf.StringSlice(carbonFlag, nil, "relative carbon intensities of the regions the recommendations are annotated and can be ranked with [format=eu-north-1=30,us-east-1=400]")
+	pf.Duration(maxPriceAgeFlag, 24*time.Hour, "the age of the prices the recommendations are warned to be based on stale prices after, disabled if 0")
+	pf.Int(maxZonesFlag, recommender.DefaultMaxZones, "the maximum number of distinct zones a recommendation request can list")
+	pf.Int(maxCandidatesFlag, 0, "the maximum number of the cheapest instance types per attribute the node pools are selected from, unlimited if 0")
+	pf.String(auditWebhookUrlFlag, "", "the address the audit records of the served recommendations are posted to, disabled if empty")
+	pf.Int(failureThresholdFlag, providerhealth.DefaultFailureThreshold, "the number of consecutive Cloud Info failures a provider is marked unhealthy after, disabled if 0")
+	pf.Duration(coolDownFlag, providerhealth.DefaultCoolDown, "the time the requests to an unhealthy provider fail fast for before it's tried again")
 	pf.Bool(devModeFlag, false, "development mode, if true token based authentication is disabled, false by default")
 	pf.String(tokenSigningKeyFlag, "", "The token signing key for the authentication process")
 	pf.String(vaultAddrFlag, ":8200", "The vault address for authentication token management")
@@ -85,3 +111,69 @@ func Configure(v *viper.Viper, pf *pflag.FlagSet) {
 	}
 
 }
+
+// parseDefaultZones parses the default zones of the regions given in region=zone1:zone2 format
+func parseDefaultZones(regionZones []string) (map[string][]string, error) {
+	defaultZones := make(map[string][]string, len(regionZones))
+	for _, rz := range regionZones {
+		parts := strings.SplitN(rz, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("invalid default zones: %s", rz)
+		}
+
+		defaultZones[parts[0]] = strings.Split(parts[1], ":")
+	}
+	return defaultZones, nil
+}
+
+// parsePrewarmRegions parses the regions to be refreshed in the background given in provider/service/region format
+func parsePrewarmRegions(regions []string) ([]productcache.Region, error) {
+	prewarmRegions := make([]productcache.Region, 0, len(regions))
+	for _, r := range regions {
+		parts := strings.Split(r, "/")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, errors.Errorf("invalid prewarm region: %s", r)
+		}
+
+		prewarmRegions = append(prewarmRegions, productcache.Region{Provider: parts[0], Service: parts[1], Region: parts[2]})
+	}
+	return prewarmRegions, nil
+}
+
+// parseCurrencyRates parses the currency conversion rates given in CODE=rate format
+func parseCurrencyRates(rates []string) (map[string]float64, error) {
+	currencyRates := make(map[string]float64, len(rates))
+	for _, r := range rates {
+		parts := strings.SplitN(r, "=", 2)
+		if len(parts) != 2 || len(parts[0]) != 3 {
+			return nil, errors.Errorf("invalid currency rate: %s", r)
+		}
+
+		rate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || rate <= 0 {
+			return nil, errors.Errorf("invalid currency rate: %s", r)
+		}
+
+		currencyRates[strings.ToUpper(parts[0])] = rate
+	}
+	return currencyRates, nil
+}
+
+// parseCarbonIntensities parses the carbon intensities of the regions given in region=intensity format
+func parseCarbonIntensities(intensities []string) (map[string]float64, error) {
+	carbonIntensities := make(map[string]float64, len(intensities))
+	for _, i := range intensities {
+		parts := strings.SplitN(i, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid carbon intensity: %s", i)
+		}
+
+		intensity, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || intensity < 0 {
+			return nil, errors.Errorf("invalid carbon intensity: %s", i)
+		}
+
+		carbonIntensities[parts[0]] = intensity
+	}
+	return carbonIntensities, nil
+}
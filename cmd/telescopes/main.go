@@ -27,15 +27,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/client"
 	"github.com/banzaicloud/telescopes/internal/app/telescopes/api"
+	"github.com/banzaicloud/telescopes/internal/app/telescopes/audit"
 	"github.com/banzaicloud/telescopes/internal/platform/buildinfo"
 	"github.com/banzaicloud/telescopes/internal/platform/log"
 	"github.com/banzaicloud/telescopes/pkg/recommender"
 	"github.com/banzaicloud/telescopes/pkg/recommender/nodepools"
+	"github.com/banzaicloud/telescopes/pkg/recommender/productcache"
+	"github.com/banzaicloud/telescopes/pkg/recommender/providerhealth"
+	"github.com/banzaicloud/telescopes/pkg/recommender/spotadvisor"
+	"github.com/banzaicloud/telescopes/pkg/recommender/throttle"
 	"github.com/banzaicloud/telescopes/pkg/recommender/vms"
 	"github.com/gin-gonic/gin"
 	httptransport "github.com/go-openapi/runtime/client"
@@ -83,7 +95,7 @@ func main() {
 	transport := httptransport.New(piUrl.Host, piUrl.Path, []string{piUrl.Scheme})
 	pc := client.New(transport, strfmt.Default)
 
-	ciCli := recommender.NewCloudInfoClient(pc)
+	ciCli := recommender.NewCloudInfoClient(pc, viper.GetDuration(cloudInfoTimeoutFlag))
 
 	// configure the gin validator
 	err = api.ConfigureValidator(ciCli)
@@ -91,10 +103,60 @@ func main() {
 
 	vmSelector := vms.NewVmSelector(logger)
 	nodePoolSelector := nodepools.NewNodePoolSelector(logger)
-	engine := recommender.NewEngine(logger, ciCli, vmSelector, nodePoolSelector)
+
+	var ciSource recommender.CloudInfoSource = ciCli
+	if maxConcurrency := viper.GetInt(maxConcurrencyFlag); maxConcurrency > 0 {
+		ciSource = throttle.NewSource(ciCli, maxConcurrency, viper.GetDuration(maxWaitFlag))
+	}
+	var healthSource *providerhealth.Source
+	if threshold := viper.GetInt(failureThresholdFlag); threshold > 0 {
+		healthSource = providerhealth.NewSource(logger, ciSource, threshold, viper.GetDuration(coolDownFlag))
+		ciSource = healthSource
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var generations api.GenerationSource
+	if ttl := viper.GetDuration(productsCacheTtlFlag); ttl > 0 {
+		cache := productcache.NewCachingSource(logger, ciSource, ttl)
+		ciSource = cache
+		generations = cache
+
+		prewarmRegions, err := parsePrewarmRegions(viper.GetStringSlice(prewarmRegionsFlag))
+		emperror.Panic(err)
+
+		if interval := viper.GetDuration(prewarmIntervalFlag); len(prewarmRegions) > 0 && interval > 0 {
+			go cache.RefreshPeriodically(ctx, interval, prewarmRegions)
+		}
+	}
+
+	var interruptionSource recommender.InterruptionSource
+	if advisorUrl := viper.GetString(spotAdvisorUrlFlag); advisorUrl != "" {
+		interruptionSource = spotadvisor.NewSpotAdvisor(logger, advisorUrl, time.Hour)
+	}
+
+	currencyRates, err := parseCurrencyRates(viper.GetStringSlice(currencyRatesFlag))
+	emperror.Panic(err)
+
+	defaultZones, err := parseDefaultZones(viper.GetStringSlice(defaultZonesFlag))
+	emperror.Panic(err)
+
+	carbonIntensities, err := parseCarbonIntensities(viper.GetStringSlice(carbonFlag))
+	emperror.Panic(err)
+
+	engine := recommender.NewEngine(logger, ciSource, vmSelector, nodePoolSelector, interruptionSource, recommender.EngineConfig{
+		CurrencyRates: currencyRates,
+		HoursPerMonth: viper.GetFloat64(hoursPerMonthFlag),
+		DefaultZones:  defaultZones,
+		MaxPriceAge:   viper.GetDuration(maxPriceAgeFlag),
+		MaxCandidates: viper.GetInt(maxCandidatesFlag),
+		MaxZones:      viper.GetInt(maxZonesFlag),
+
+		CarbonIntensities: carbonIntensities,
+	})
 
 	buildInfo := buildinfo.New(Version, CommitHash, BuildDate)
-	routeHandler := api.NewRouteHandler(engine, buildInfo, ciCli, logger)
+	routeHandler := api.NewRouteHandler(engine, buildInfo, ciCli, ciSource, logger)
 
 	// new default gin engine (recovery, logger middleware)
 	router := gin.Default()
@@ -113,10 +175,31 @@ func main() {
 		routeHandler.EnableMetrics(router, config.Metrics.Address)
 	}
 
+	if webhookUrl := viper.GetString(auditWebhookUrlFlag); webhookUrl != "" {
+		webhook := audit.NewWebhook(logger, webhookUrl)
+		defer webhook.Close()
+
+		routeHandler.EnableAudit(webhook)
+	}
+
+	if healthSource != nil {
+		routeHandler.EnableProviderHealth(healthSource)
+	}
+
+	if generations != nil {
+		routeHandler.EnableETags(generations, viper.GetDuration(maxPriceAgeFlag))
+	}
+
 	routeHandler.ConfigureRoutes(router)
 	logger.Info("configured routes")
 
-	err = router.Run(viper.GetString(listenAddressFlag))
+	listener, err := net.Listen("tcp", viper.GetString(listenAddressFlag))
+	emperror.Panic(errors.Wrap(err, "failed to listen"))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	err = serve(&http.Server{Handler: router}, listener, stop, viper.GetDuration(shutdownTimeoutFlag), logger)
 	emperror.Panic(errors.Wrap(err, "failed to run router"))
 }
 
@@ -16,9 +16,15 @@ package main
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/banzaicloud/telescopes/pkg/recommender/productcache"
+	"github.com/goph/logur"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -106,6 +112,22 @@ func Test_configurationStringDefaults(t *testing.T) {
 				assert.Equal(t, ":9090", val, fmt.Sprintf("invalid default for %s", listenAddressFlag))
 			},
 		},
+		{
+			name:     fmt.Sprintf("defaults for: %s", cloudInfoTimeoutFlag),
+			viperKey: cloudInfoTimeoutFlag,
+			args:     []string{}, // no flags provided
+			check: func(val interface{}) {
+				assert.Equal(t, "10s", val, fmt.Sprintf("invalid default for %s", cloudInfoTimeoutFlag))
+			},
+		},
+		{
+			name:     fmt.Sprintf("defaults for: %s", shutdownTimeoutFlag),
+			viperKey: shutdownTimeoutFlag,
+			args:     []string{}, // no flags provided
+			check: func(val interface{}) {
+				assert.Equal(t, "30s", val, fmt.Sprintf("invalid default for %s", shutdownTimeoutFlag))
+			},
+		},
 		{
 			name:     fmt.Sprintf("defaults for: %s", devModeFlag),
 			viperKey: devModeFlag,
@@ -144,3 +166,173 @@ func Test_configurationStringDefaults(t *testing.T) {
 		})
 	}
 }
+
+func Test_serveGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		// simulate a slow recommendation
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err, "the error should be nil")
+
+	stop := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(&http.Server{Handler: handler}, listener, stop, 5*time.Second, logur.NewTestLogger())
+	}()
+
+	statusCodes := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("http://%s/api/v1/recommender", listener.Addr()))
+		if err != nil {
+			statusCodes <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		statusCodes <- resp.StatusCode
+	}()
+
+	// shut down while the request is in flight
+	<-started
+	stop <- syscall.SIGTERM
+
+	assert.Equal(t, http.StatusOK, <-statusCodes, "the in-flight request should complete")
+	assert.Nil(t, <-served, "the server should shut down gracefully")
+}
+
+func Test_parseDefaultZones(t *testing.T) {
+	tests := []struct {
+		name        string
+		regionZones []string
+		check       func(zones map[string][]string, err error)
+	}{
+		{
+			name:        "default zones parsed",
+			regionZones: []string{"eu-west-1=eu-west-1a:eu-west-1b", "us-east-1=us-east-1c"},
+			check: func(zones map[string][]string, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, map[string][]string{"eu-west-1": {"eu-west-1a", "eu-west-1b"}, "us-east-1": {"us-east-1c"}}, zones)
+			},
+		},
+		{
+			name:        "missing zones",
+			regionZones: []string{"eu-west-1="},
+			check: func(zones map[string][]string, err error) {
+				assert.EqualError(t, err, "invalid default zones: eu-west-1=")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(parseDefaultZones(test.regionZones))
+		})
+	}
+}
+
+func Test_parsePrewarmRegions(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []string
+		check   func(regions []productcache.Region, err error)
+	}{
+		{
+			name:    "prewarm regions parsed",
+			regions: []string{"amazon/compute/eu-west-1", "google/compute/europe-west1"},
+			check: func(regions []productcache.Region, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []productcache.Region{
+					{Provider: "amazon", Service: "compute", Region: "eu-west-1"},
+					{Provider: "google", Service: "compute", Region: "europe-west1"},
+				}, regions)
+			},
+		},
+		{
+			name:    "missing service",
+			regions: []string{"amazon/eu-west-1"},
+			check: func(regions []productcache.Region, err error) {
+				assert.EqualError(t, err, "invalid prewarm region: amazon/eu-west-1")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(parsePrewarmRegions(test.regions))
+		})
+	}
+}
+
+func Test_parseCurrencyRates(t *testing.T) {
+	tests := []struct {
+		name  string
+		rates []string
+		check func(rates map[string]float64, err error)
+	}{
+		{
+			name:  "rates parsed",
+			rates: []string{"EUR=0.88", "gbp=0.77"},
+			check: func(rates map[string]float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, map[string]float64{"EUR": 0.88, "GBP": 0.77}, rates)
+			},
+		},
+		{
+			name:  "invalid currency code",
+			rates: []string{"EURO=0.88"},
+			check: func(rates map[string]float64, err error) {
+				assert.EqualError(t, err, "invalid currency rate: EURO=0.88")
+			},
+		},
+		{
+			name:  "invalid rate",
+			rates: []string{"EUR=-1"},
+			check: func(rates map[string]float64, err error) {
+				assert.EqualError(t, err, "invalid currency rate: EUR=-1")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(parseCurrencyRates(test.rates))
+		})
+	}
+}
+
+func Test_parseCarbonIntensities(t *testing.T) {
+	tests := []struct {
+		name        string
+		intensities []string
+		check       func(intensities map[string]float64, err error)
+	}{
+		{
+			name:        "intensities parsed",
+			intensities: []string{"eu-north-1=30", "us-east-1=400.5"},
+			check: func(intensities map[string]float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, map[string]float64{"eu-north-1": 30, "us-east-1": 400.5}, intensities)
+			},
+		},
+		{
+			name:        "missing region",
+			intensities: []string{"=30"},
+			check: func(intensities map[string]float64, err error) {
+				assert.EqualError(t, err, "invalid carbon intensity: =30")
+			},
+		},
+		{
+			name:        "invalid intensity",
+			intensities: []string{"eu-north-1=low"},
+			check: func(intensities map[string]float64, err error) {
+				assert.EqualError(t, err, "invalid carbon intensity: eu-north-1=low")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(parseCarbonIntensities(test.intensities))
+		})
+	}
+}
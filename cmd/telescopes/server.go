@@ -0,0 +1,47 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+)
+
+// serve runs the http server on the listener until a signal arrives on the stop channel,
+// then shuts the server down gracefully: in-flight requests are allowed to complete within the timeout
+func serve(server *http.Server, listener net.Listener, stop <-chan os.Signal, timeout time.Duration, logger logur.Logger) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errs:
+		return emperror.Wrap(err, "server stopped unexpectedly")
+	case sig := <-stop:
+		logger.Info("shutting down the server", map[string]interface{}{"signal": sig.String(), "timeout": timeout.String()})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return emperror.Wrap(server.Shutdown(ctx), "failed to shut down the server gracefully")
+}
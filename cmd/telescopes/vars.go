@@ -22,16 +22,34 @@ const (
 
 	// the list of flags supported by the application
 	// these constants can be used to retrieve the passed in values or defaults via viper
-	logLevelFlag        = "log-level"
-	logFormatFlag       = "log-format"
-	listenAddressFlag   = "listen-address"
-	cloudInfoFlag       = "cloudinfo-address"
-	devModeFlag         = "dev-mode"
-	tokenSigningKeyFlag = "tokensigningkey"
-	vaultAddrFlag       = "vault-address"
-	helpFlag            = "help"
-	metricsEnabledFlag  = "metrics-enabled"
-	metricsAddressFlag  = "metrics-address"
+	logLevelFlag         = "log-level"
+	logFormatFlag        = "log-format"
+	listenAddressFlag    = "listen-address"
+	shutdownTimeoutFlag  = "shutdown-timeout"
+	cloudInfoFlag        = "cloudinfo-address"
+	cloudInfoTimeoutFlag = "cloudinfo-timeout"
+	maxConcurrencyFlag   = "cloudinfo-max-concurrency"
+	maxWaitFlag          = "cloudinfo-max-wait"
+	productsCacheTtlFlag = "products-cache-ttl"
+	prewarmRegionsFlag   = "prewarm-regions"
+	prewarmIntervalFlag  = "prewarm-interval"
+	spotAdvisorUrlFlag   = "spot-advisor-url"
+	currencyRatesFlag    = "currency-rates"
+	hoursPerMonthFlag    = "hours-per-month"
+	defaultZonesFlag     = "default-zones"
+	maxPriceAgeFlag      = "max-price-age"
+	carbonFlag           = "carbon-intensities"
+	maxCandidatesFlag    = "max-candidates"
+	maxZonesFlag         = "max-zones"
+	auditWebhookUrlFlag  = "audit-webhook-url"
+	failureThresholdFlag = "provider-failure-threshold"
+	coolDownFlag         = "provider-cool-down"
+	devModeFlag          = "dev-mode"
+	tokenSigningKeyFlag  = "tokensigningkey"
+	vaultAddrFlag        = "vault-address"
+	helpFlag             = "help"
+	metricsEnabledFlag   = "metrics-enabled"
+	metricsAddressFlag   = "metrics-address"
 
 	cfgAppRole = "telescopes-app-role"
 )
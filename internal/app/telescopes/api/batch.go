@@ -0,0 +1,81 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"sync"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/emperror"
+)
+
+const (
+	// maxBatchSize is the maximum number of items accepted in a batch recommendation request
+	maxBatchSize = 100
+	// batchConcurrency is the number of batch items recommended in parallel
+	batchConcurrency = 4
+)
+
+// recommendFunc performs the recommendation for a single batch item
+type recommendFunc func(item BatchRecommendationItem) (*recommender.ClusterRecommendationResp, error)
+
+// runBatch performs the recommendations for the batch items using a bounded number of workers
+// a failing item doesn't affect the others; items not started before the context is done are reported as failed
+func runBatch(ctx context.Context, items []BatchRecommendationItem, concurrency int, recommend recommendFunc) []BatchRecommendationResult {
+	results := make([]BatchRecommendationResult, len(items))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = batchResult(ctx, items[i], recommend)
+			}
+		}()
+	}
+
+	for i := range items {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// batchResult performs the recommendation for a single batch item unless the context is already done
+func batchResult(ctx context.Context, item BatchRecommendationItem, recommend recommendFunc) BatchRecommendationResult {
+	result := BatchRecommendationResult{
+		Provider: item.Provider,
+		Service:  item.Service,
+		Region:   item.Region,
+	}
+
+	if err := ctx.Err(); err != nil {
+		result.Error = emperror.Wrap(err, "recommendation not performed").Error()
+		return result
+	}
+
+	response, err := recommend(item)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Response = response
+	return result
+}
@@ -0,0 +1,85 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/stretchr/testify/assert"
+)
+
+// dummyRecommend fails for the items in unknown regions
+func dummyRecommend(item BatchRecommendationItem) (*recommender.ClusterRecommendationResp, error) {
+	if item.Region == "unknown" {
+		return nil, errors.New("region not found: unknown")
+	}
+	return &recommender.ClusterRecommendationResp{Provider: item.Provider, Service: item.Service, Region: item.Region}, nil
+}
+
+func Test_runBatch(t *testing.T) {
+	items := []BatchRecommendationItem{
+		{Provider: "amazon", Service: "compute", Region: "eu-west-1"},
+		{Provider: "amazon", Service: "compute", Region: "unknown"},
+		{Provider: "google", Service: "compute", Region: "europe-west1"},
+	}
+	tests := []struct {
+		name  string
+		ctx   func() context.Context
+		check func(results []BatchRecommendationResult)
+	}{
+		{
+			name: "valid and invalid items",
+			ctx:  context.Background,
+			check: func(results []BatchRecommendationResult) {
+				assert.Equal(t, 3, len(results))
+
+				assert.Equal(t, "eu-west-1", results[0].Region)
+				assert.Equal(t, "eu-west-1", results[0].Response.Region)
+				assert.Empty(t, results[0].Error)
+
+				assert.Equal(t, "unknown", results[1].Region)
+				assert.Nil(t, results[1].Response)
+				assert.Equal(t, "region not found: unknown", results[1].Error)
+
+				assert.Equal(t, "europe-west1", results[2].Region)
+				assert.Equal(t, "google", results[2].Response.Provider)
+				assert.Empty(t, results[2].Error)
+			},
+		},
+		{
+			name: "canceled context",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			check: func(results []BatchRecommendationResult) {
+				assert.Equal(t, 3, len(results))
+				for _, result := range results {
+					assert.Nil(t, result.Response)
+					assert.Contains(t, result.Error, "context canceled")
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(runBatch(test.ctx(), items, 2, dummyRecommend))
+		})
+	}
+}
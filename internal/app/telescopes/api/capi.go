@@ -0,0 +1,102 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/pkg/errors"
+)
+
+// formatCapi is the value of the format query parameter requesting Cluster API manifests
+const formatCapi = "capi"
+
+// capiMachineDeploymentName names the machine deployment (and its machine template) of a node pool
+// the cluster name is left as a clusterctl variable, eg. ${CLUSTER_NAME}-spot-m5-xlarge
+func capiMachineDeploymentName(np recommender.NodePool) string {
+	class := "on-demand"
+	if np.VmClass == recommender.Spot {
+		class = "spot"
+	}
+	return fmt.Sprintf("${CLUSTER_NAME}-%s-%s", class, strings.Replace(np.VmType.Type, ".", "-", -1))
+}
+
+// writeCapiMachineDeployments renders the recommended worker node pools as Cluster API MachineDeployment and AWSMachineTemplate pairs
+// the cluster name, the kubernetes version and the ssh key are left as clusterctl variables, the master node pools are left out
+func writeCapiMachineDeployments(w io.Writer, resp recommender.ClusterRecommendationResp) error {
+	if resp.Provider != "amazon" {
+		return errors.Errorf("the capi format is only available for amazon, not %s", resp.Provider)
+	}
+
+	bw := bufio.NewWriter(w)
+	first := true
+	for _, np := range resp.NodePools {
+		if np.Role == recommender.Master || np.SumNodes == 0 {
+			continue
+		}
+		if !first {
+			fmt.Fprintln(bw, "---")
+		}
+		first = false
+
+		name := capiMachineDeploymentName(np)
+		fmt.Fprintln(bw, "apiVersion: cluster.x-k8s.io/v1beta1")
+		fmt.Fprintln(bw, "kind: MachineDeployment")
+		fmt.Fprintln(bw, "metadata:")
+		fmt.Fprintf(bw, "  name: %s\n", name)
+		fmt.Fprintln(bw, "spec:")
+		fmt.Fprintln(bw, "  clusterName: ${CLUSTER_NAME}")
+		fmt.Fprintf(bw, "  replicas: %d\n", np.SumNodes)
+		fmt.Fprintln(bw, "  selector:")
+		fmt.Fprintln(bw, "    matchLabels: {}")
+		fmt.Fprintln(bw, "  template:")
+		fmt.Fprintln(bw, "    spec:")
+		fmt.Fprintln(bw, "      clusterName: ${CLUSTER_NAME}")
+		fmt.Fprintln(bw, "      version: ${KUBERNETES_VERSION}")
+		if np.Zone != "" {
+			fmt.Fprintf(bw, "      failureDomain: %s\n", np.Zone)
+		}
+		fmt.Fprintln(bw, "      bootstrap:")
+		fmt.Fprintln(bw, "        configRef:")
+		fmt.Fprintln(bw, "          apiVersion: bootstrap.cluster.x-k8s.io/v1beta1")
+		fmt.Fprintln(bw, "          kind: KubeadmConfigTemplate")
+		fmt.Fprintln(bw, "          name: ${CLUSTER_NAME}-md-0")
+		fmt.Fprintln(bw, "      infrastructureRef:")
+		fmt.Fprintln(bw, "        apiVersion: infrastructure.cluster.x-k8s.io/v1beta2")
+		fmt.Fprintln(bw, "        kind: AWSMachineTemplate")
+		fmt.Fprintf(bw, "        name: %s\n", name)
+		fmt.Fprintln(bw, "---")
+		fmt.Fprintln(bw, "apiVersion: infrastructure.cluster.x-k8s.io/v1beta2")
+		fmt.Fprintln(bw, "kind: AWSMachineTemplate")
+		fmt.Fprintln(bw, "metadata:")
+		fmt.Fprintf(bw, "  name: %s\n", name)
+		fmt.Fprintln(bw, "spec:")
+		fmt.Fprintln(bw, "  template:")
+		fmt.Fprintln(bw, "    spec:")
+		fmt.Fprintf(bw, "      instanceType: %s\n", np.VmType.Type)
+		fmt.Fprintln(bw, "      iamInstanceProfile: nodes.cluster-api-provider-aws.sigs.k8s.io")
+		fmt.Fprintln(bw, "      sshKeyName: ${AWS_SSH_KEY_NAME}")
+		if np.VmClass == recommender.Spot {
+			// no max price: spot instances are capped at the on-demand price
+			fmt.Fprintln(bw, "      spotMarketOptions: {}")
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,68 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+)
+
+// formatCsv is the value of the format query parameter requesting CSV output
+const formatCsv = "csv"
+
+// csvHeader holds the column names of the node pools CSV export
+var csvHeader = []string{"instanceType", "vmClass", "cpus", "memory", "gpus", "onDemandPrice", "avgPrice", "sumNodes", "monthlyCost"}
+
+// writeNodePoolsCsv renders the recommended node pools as CSV, one node pool per line
+func writeNodePoolsCsv(w io.Writer, nodePools []recommender.NodePool) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, np := range nodePools {
+		record := []string{
+			np.VmType.Type,
+			np.VmClass,
+			formatFloat(np.VmType.Cpus),
+			formatFloat(np.VmType.Mem),
+			formatFloat(np.VmType.Gpus),
+			formatFloat(np.VmType.OnDemandPrice),
+			formatFloat(np.VmType.AvgPrice),
+			strconv.Itoa(np.SumNodes),
+			formatFloat(np.MonthlyCost),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvFileName assembles the name of the CSV file the recommendation is exported to
+func csvFileName(resp recommender.ClusterRecommendationResp) string {
+	return fmt.Sprintf("recommendation-%s-%s-%s.csv", resp.Provider, resp.Service, resp.Region)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
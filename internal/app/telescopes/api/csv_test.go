@@ -0,0 +1,64 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_writeNodePoolsCsv(t *testing.T) {
+	nodePools := []recommender.NodePool{
+		{
+			VmType: recommender.VirtualMachine{
+				Type:          "c5.xlarge",
+				Cpus:          4,
+				Mem:           8,
+				OnDemandPrice: 0.192,
+				AvgPrice:      0.0733,
+			},
+			SumNodes:    2,
+			VmClass:     recommender.Regular,
+			Role:        recommender.Worker,
+			MonthlyCost: 280.32,
+		},
+		{
+			VmType: recommender.VirtualMachine{
+				Type:          "p2.xlarge",
+				Cpus:          4,
+				Mem:           61,
+				Gpus:          1,
+				OnDemandPrice: 0.972,
+				AvgPrice:      0.2916,
+			},
+			SumNodes:    3,
+			VmClass:     recommender.Spot,
+			Role:        recommender.Worker,
+			MonthlyCost: 638.604,
+		},
+	}
+
+	var buf bytes.Buffer
+	err := writeNodePoolsCsv(&buf, nodePools)
+	assert.Nil(t, err, "the error should be nil")
+
+	golden, err := ioutil.ReadFile("testdata/nodepools.csv")
+	assert.Nil(t, err, "the golden file should be readable")
+	assert.Equal(t, string(golden), buf.String())
+}
@@ -0,0 +1,76 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/banzaicloud/telescopes/internal/platform/classifier"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/emperror"
+	"github.com/pkg/errors"
+)
+
+// recommendation holds the outcome of a recommendation performed in the background
+type recommendation struct {
+	response *recommender.ClusterRecommendationResp
+	err      error
+}
+
+// recommendCtxFunc performs a recommendation retrieving the product details within the context
+type recommendCtxFunc func(ctx context.Context) (*recommender.ClusterRecommendationResp, error)
+
+// withDeadline returns the context the recommendations of a request are performed in, 0 timeout means no deadline
+// the recommendations of a request performing more than one share it, so the whole request completes within the timeout
+func withDeadline(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// recommendWithDeadline performs the recommendation within the given timeout, 0 means no deadline
+func recommendWithDeadline(ctx context.Context, timeout time.Duration, recommend recommendCtxFunc) (*recommender.ClusterRecommendationResp, error) {
+	ctx, cancel := withDeadline(ctx, timeout)
+	defer cancel()
+
+	return recommendBefore(ctx, timeout, recommend)
+}
+
+// recommendBefore performs the recommendation before the deadline of the context set by withDeadline for the given timeout
+// the engine can't be interrupted, a recommendation exceeding the deadline completes in the background and its result is dropped
+func recommendBefore(ctx context.Context, timeout time.Duration, recommend recommendCtxFunc) (*recommender.ClusterRecommendationResp, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return recommend(ctx)
+	}
+
+	// buffered, so that the background recommendation doesn't block if the result is dropped
+	results := make(chan recommendation, 1)
+	go func() {
+		response, err := recommend(ctx)
+		results <- recommendation{response: response, err: err}
+	}()
+
+	select {
+	case result := <-results:
+		return result.response, result.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, emperror.With(errors.Errorf("recommendation did not complete in %s", timeout), classifier.TimeoutErrTag)
+		}
+		return nil, emperror.Wrap(ctx.Err(), "recommendation aborted")
+	}
+}
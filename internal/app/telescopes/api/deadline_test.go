@@ -0,0 +1,97 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/telescopes/internal/platform/errorresponse"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowRecommend simulates a recommendation against a slow product registry
+func slowRecommend(delay time.Duration) recommendCtxFunc {
+	return func(ctx context.Context) (*recommender.ClusterRecommendationResp, error) {
+		time.Sleep(delay)
+		return &recommender.ClusterRecommendationResp{Provider: "amazon"}, nil
+	}
+}
+
+func Test_recommendWithDeadline(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		recommend recommendCtxFunc
+		check     func(resp *recommender.ClusterRecommendationResp, code int)
+	}{
+		{
+			name:      "recommendation completes in time",
+			timeout:   time.Second,
+			recommend: slowRecommend(0),
+			check: func(resp *recommender.ClusterRecommendationResp, code int) {
+				assert.Equal(t, "amazon", resp.Provider)
+			},
+		},
+		{
+			name:      "no deadline",
+			timeout:   0,
+			recommend: slowRecommend(10 * time.Millisecond),
+			check: func(resp *recommender.ClusterRecommendationResp, code int) {
+				assert.Equal(t, "amazon", resp.Provider)
+			},
+		},
+		{
+			name:      "deadline exceeded",
+			timeout:   time.Millisecond,
+			recommend: slowRecommend(200 * time.Millisecond),
+			check: func(resp *recommender.ClusterRecommendationResp, code int) {
+				assert.Nil(t, resp, "the response should be nil")
+				assert.Equal(t, http.StatusGatewayTimeout, code)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			resp, err := recommendWithDeadline(context.Background(), test.timeout, test.recommend)
+			if err != nil {
+				errorresponse.NewErrorResponder(c).Respond(err)
+			}
+			test.check(resp, w.Code)
+		})
+	}
+}
+
+func Test_recommendBefore(t *testing.T) {
+	ctx, cancel := withDeadline(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	resp, err := recommendBefore(ctx, 50*time.Millisecond, slowRecommend(30*time.Millisecond))
+	assert.Nil(t, err, "the error should be nil")
+	assert.Equal(t, "amazon", resp.Provider)
+
+	// the second recommendation of the request only has the rest of the shared deadline
+	resp, err = recommendBefore(ctx, 50*time.Millisecond, slowRecommend(30*time.Millisecond))
+	assert.EqualError(t, err, "recommendation did not complete in 50ms")
+	assert.Nil(t, resp, "the response should be nil")
+}
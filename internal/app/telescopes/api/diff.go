@@ -0,0 +1,92 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+)
+
+// nodePoolKey identifies the node pools of the recommendations compared
+type nodePoolKey struct {
+	vmType  string
+	vmClass string
+	role    string
+}
+
+func keyOf(np recommender.NodePool) nodePoolKey {
+	return nodePoolKey{vmType: np.VmType.Type, vmClass: np.VmClass, role: np.Role}
+}
+
+// nodePrice returns the hourly price of a node of the node pool by its class
+func nodePrice(np recommender.NodePool) float64 {
+	if np.VmClass == recommender.Spot {
+		return np.VmType.AvgPrice
+	}
+	return np.VmType.OnDemandPrice
+}
+
+// diffRecommendations compares the current recommendation to the previous one
+// the added and changed node pools are listed in the order of the current, the removed ones in the order of the previous recommendation
+// the prices of recommendations in different currencies can't be compared
+func diffRecommendations(previous, current *recommender.ClusterRecommendationResp) (DiffResponse, error) {
+	if previous.Currency != "" && previous.Currency != current.Currency {
+		return DiffResponse{}, fmt.Errorf("the previous recommendation is in %s, the current one in %s", previous.Currency, current.Currency)
+	}
+
+	diff := DiffResponse{
+		Currency:           current.Currency,
+		Added:              make([]NodePoolDiff, 0),
+		Removed:            make([]NodePoolDiff, 0),
+		Changed:            make([]NodePoolDiff, 0),
+		PreviousTotalPrice: previous.Accuracy.RecTotalPrice,
+		TotalPrice:         current.Accuracy.RecTotalPrice,
+		Current:            current,
+	}
+
+	previousPools := make(map[nodePoolKey]recommender.NodePool, len(previous.NodePools))
+	for _, np := range previous.NodePools {
+		previousPools[keyOf(np)] = np
+	}
+
+	currentPools := make(map[nodePoolKey]bool, len(current.NodePools))
+	for _, np := range current.NodePools {
+		key := keyOf(np)
+		currentPools[key] = true
+
+		change := NodePoolDiff{Type: np.VmType.Type, VmClass: np.VmClass, Role: np.Role, Nodes: np.SumNodes, Price: nodePrice(np)}
+		prev, ok := previousPools[key]
+		if !ok {
+			diff.Added = append(diff.Added, change)
+			continue
+		}
+
+		change.PreviousNodes, change.PreviousPrice = prev.SumNodes, nodePrice(prev)
+		if change.PreviousNodes != change.Nodes || change.PreviousPrice != change.Price {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+
+	for _, np := range previous.NodePools {
+		if !currentPools[keyOf(np)] {
+			diff.Removed = append(diff.Removed, NodePoolDiff{
+				Type: np.VmType.Type, VmClass: np.VmClass, Role: np.Role, PreviousNodes: np.SumNodes, PreviousPrice: nodePrice(np),
+			})
+		}
+	}
+
+	return diff, nil
+}
@@ -0,0 +1,93 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_diffRecommendations(t *testing.T) {
+	previous := &recommender.ClusterRecommendationResp{
+		Currency: "USD",
+		NodePools: []recommender.NodePool{
+			{VmType: recommender.VirtualMachine{Type: "m5.xlarge", OnDemandPrice: 0.192}, SumNodes: 1, VmClass: recommender.Regular, Role: recommender.Worker},
+			{VmType: recommender.VirtualMachine{Type: "m5.xlarge", AvgPrice: 0.07}, SumNodes: 2, VmClass: recommender.Spot, Role: recommender.Worker},
+			{VmType: recommender.VirtualMachine{Type: "m4.xlarge", AvgPrice: 0.06}, SumNodes: 2, VmClass: recommender.Spot, Role: recommender.Worker},
+			{VmType: recommender.VirtualMachine{Type: "c5.xlarge", AvgPrice: 0.08}, SumNodes: 1, VmClass: recommender.Spot, Role: recommender.Worker},
+		},
+		Accuracy: recommender.ClusterRecommendationAccuracy{RecTotalPrice: 0.532},
+	}
+	tests := []struct {
+		name    string
+		current *recommender.ClusterRecommendationResp
+		check   func(diff DiffResponse, err error)
+	}{
+		{
+			name:    "no changes",
+			current: previous,
+			check: func(diff DiffResponse, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Empty(t, diff.Added)
+				assert.Empty(t, diff.Removed)
+				assert.Empty(t, diff.Changed)
+				assert.Equal(t, diff.PreviousTotalPrice, diff.TotalPrice)
+			},
+		},
+		{
+			name: "types added, removed and repriced",
+			current: &recommender.ClusterRecommendationResp{
+				Currency: "USD",
+				NodePools: []recommender.NodePool{
+					{VmType: recommender.VirtualMachine{Type: "m5.xlarge", OnDemandPrice: 0.192}, SumNodes: 1, VmClass: recommender.Regular, Role: recommender.Worker},
+					{VmType: recommender.VirtualMachine{Type: "m5.xlarge", AvgPrice: 0.09}, SumNodes: 2, VmClass: recommender.Spot, Role: recommender.Worker},
+					{VmType: recommender.VirtualMachine{Type: "m4.xlarge", AvgPrice: 0.06}, SumNodes: 3, VmClass: recommender.Spot, Role: recommender.Worker},
+					{VmType: recommender.VirtualMachine{Type: "m5a.xlarge", AvgPrice: 0.05}, SumNodes: 1, VmClass: recommender.Spot, Role: recommender.Worker},
+				},
+				Accuracy: recommender.ClusterRecommendationAccuracy{RecTotalPrice: 0.602},
+			},
+			check: func(diff DiffResponse, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "USD", diff.Currency)
+				assert.Equal(t, []NodePoolDiff{
+					{Type: "m5a.xlarge", VmClass: recommender.Spot, Role: recommender.Worker, Nodes: 1, Price: 0.05},
+				}, diff.Added)
+				assert.Equal(t, []NodePoolDiff{
+					{Type: "c5.xlarge", VmClass: recommender.Spot, Role: recommender.Worker, PreviousNodes: 1, PreviousPrice: 0.08},
+				}, diff.Removed)
+				assert.Equal(t, []NodePoolDiff{
+					{Type: "m5.xlarge", VmClass: recommender.Spot, Role: recommender.Worker, PreviousNodes: 2, Nodes: 2, PreviousPrice: 0.07, Price: 0.09},
+					{Type: "m4.xlarge", VmClass: recommender.Spot, Role: recommender.Worker, PreviousNodes: 2, Nodes: 3, PreviousPrice: 0.06, Price: 0.06},
+				}, diff.Changed)
+				assert.Equal(t, 0.532, diff.PreviousTotalPrice)
+				assert.Equal(t, 0.602, diff.TotalPrice)
+			},
+		},
+		{
+			name:    "different currencies",
+			current: &recommender.ClusterRecommendationResp{Currency: "EUR"},
+			check: func(diff DiffResponse, err error) {
+				assert.EqualError(t, err, "the previous recommendation is in USD, the current one in EUR")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(diffRecommendations(previous, test.current))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/pkg/errors"
+)
+
+// formatEksctl is the value of the format query parameter requesting an eksctl node groups fragment
+const formatEksctl = "eksctl"
+
+// eksctlNodeGroup is a managed node group of an eksctl cluster config
+type eksctlNodeGroup struct {
+	name          string
+	instanceTypes []string
+	spot          bool
+	capacity      int
+}
+
+// eksctlNodeGroups groups the recommended worker node pools into an on-demand and a spot managed node group
+// the master node pools are left out, as the EKS control plane is managed by AWS
+func eksctlNodeGroups(nodePools []recommender.NodePool) []eksctlNodeGroup {
+	onDemand := eksctlNodeGroup{name: "on-demand"}
+	spot := eksctlNodeGroup{name: "spot", spot: true}
+
+	for _, np := range nodePools {
+		if np.Role == recommender.Master || np.SumNodes == 0 {
+			continue
+		}
+		group := &onDemand
+		if np.VmClass == recommender.Spot {
+			group = &spot
+		}
+		if !contains(group.instanceTypes, np.VmType.Type) {
+			group.instanceTypes = append(group.instanceTypes, np.VmType.Type)
+		}
+		group.capacity += np.SumNodes
+	}
+
+	var groups []eksctlNodeGroup
+	for _, group := range []eksctlNodeGroup{onDemand, spot} {
+		if group.capacity > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// writeEksctlNodeGroups renders the recommended node pools as the managedNodeGroups fragment of an eksctl cluster config
+// the node groups are sized to the recommended node count
+func writeEksctlNodeGroups(w io.Writer, resp recommender.ClusterRecommendationResp) error {
+	if resp.Provider != "amazon" {
+		return errors.Errorf("the eksctl format is only available for amazon, not %s", resp.Provider)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "managedNodeGroups:")
+	for _, group := range eksctlNodeGroups(resp.NodePools) {
+		quoted := make([]string, len(group.instanceTypes))
+		for i, instanceType := range group.instanceTypes {
+			quoted[i] = strconv.Quote(instanceType)
+		}
+
+		fmt.Fprintf(bw, "  - name: %s\n", group.name)
+		fmt.Fprintf(bw, "    instanceTypes: [%s]\n", strings.Join(quoted, ", "))
+		fmt.Fprintf(bw, "    spot: %t\n", group.spot)
+		fmt.Fprintf(bw, "    desiredCapacity: %d\n", group.capacity)
+		fmt.Fprintf(bw, "    minSize: %d\n", group.capacity)
+		fmt.Fprintf(bw, "    maxSize: %d\n", group.capacity)
+	}
+
+	return bw.Flush()
+}
+
+func contains(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
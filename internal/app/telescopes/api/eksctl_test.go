@@ -0,0 +1,64 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_writeEksctlNodeGroups(t *testing.T) {
+	nodePools := []recommender.NodePool{
+		{VmType: recommender.VirtualMachine{Type: "m5.xlarge"}, SumNodes: 1, VmClass: recommender.Regular, Role: recommender.Worker},
+		{VmType: recommender.VirtualMachine{Type: "m5.xlarge"}, SumNodes: 3, VmClass: recommender.Spot, Role: recommender.Worker},
+		{VmType: recommender.VirtualMachine{Type: "m5a.xlarge"}, SumNodes: 2, VmClass: recommender.Spot, Role: recommender.Worker},
+		{VmType: recommender.VirtualMachine{Type: "c5.xlarge"}, SumNodes: 0, VmClass: recommender.Spot, Role: recommender.Worker},
+		{VmType: recommender.VirtualMachine{Type: "m5.large"}, SumNodes: 1, VmClass: recommender.Regular, Role: recommender.Master},
+	}
+	tests := []struct {
+		name     string
+		provider string
+		check    func(yaml string, err error)
+	}{
+		{
+			name:     "two type spot node group",
+			provider: "amazon",
+			check: func(yaml string, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				golden, err := ioutil.ReadFile("testdata/nodegroups.yaml")
+				assert.Nil(t, err, "the golden file should be readable")
+				assert.Equal(t, string(golden), yaml)
+			},
+		},
+		{
+			name:     "other providers rejected",
+			provider: "google",
+			check: func(yaml string, err error) {
+				assert.EqualError(t, err, "the eksctl format is only available for amazon, not google")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := writeEksctlNodeGroups(&buf, recommender.ClusterRecommendationResp{Provider: test.provider, NodePools: nodePools})
+			test.check(buf.String(), err)
+		})
+	}
+}
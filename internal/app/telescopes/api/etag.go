@@ -0,0 +1,145 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/gin-gonic/gin"
+)
+
+// GenerationSource tells the generation of the product details of a region, it changes whenever they are retrieved again
+type GenerationSource interface {
+	// Generation returns the generation of the product details of the region, false if it's unknown
+	Generation(provider string, service string, region string) (time.Time, bool)
+}
+
+// etagMiddleware answers the repeated cluster recommendation requests with 304 Not Modified while their ETag doesn't change
+func (r *RouteHandler) etagMiddleware(c *gin.Context) {
+	if r.generations == nil {
+		return
+	}
+
+	provider, service, region := strings.ToLower(c.Param("provider")), strings.ToLower(c.Param("service")), strings.ToLower(c.Param("region"))
+	generation, ok := r.generations.Generation(provider, service, region)
+	if !ok || r.stalePrices(c.Request.Context(), provider, service, region) {
+		// the recommendation depends on the time too, it's warned about the age of the prices
+		return
+	}
+
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return
+	}
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var req recommender.ClusterRecommendationReq
+	if err := json.Unmarshal(body, &req); err != nil {
+		// the request is rejected by the handler
+		return
+	}
+	if req.Objective == recommender.Stability {
+		// the recommendation depends on the spot advisor ratings too
+		return
+	}
+
+	etag := recommendationETag(strings.Join([]string{provider, service, region}, "/"), c.Request.URL.Query().Encode(), req, generation)
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Header("ETag", weakETag(etag))
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	}
+
+	// the failed recommendations are not tagged
+	c.Writer = &etagWriter{ResponseWriter: c.Writer, etag: etag}
+}
+
+// stalePrices checks whether the prices of the cached product details of the region are older than the max price age
+func (r *RouteHandler) stalePrices(ctx context.Context, provider string, service string, region string) bool {
+	if r.maxPriceAge <= 0 {
+		return false
+	}
+
+	vms, err := recommender.ProductDetails(ctx, r.ciSource, provider, service, region)
+	if err != nil {
+		// deliberately considered stale, so the request isn't tagged: the handler fails on the same error
+		return true
+	}
+	updated := recommender.PricesUpdated(vms)
+	return !updated.IsZero() && time.Since(updated) > r.maxPriceAge
+}
+
+// etagWriter sets the ETag header on the successful responses only
+type etagWriter struct {
+	gin.ResponseWriter
+	etag string
+}
+
+func (w *etagWriter) tag() {
+	if !w.Written() && w.Status() == http.StatusOK {
+		w.Header().Set("ETag", weakETag(w.etag))
+	}
+}
+
+func (w *etagWriter) WriteHeaderNow() {
+	w.tag()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *etagWriter) Write(data []byte) (int, error) {
+	w.tag()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	w.tag()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// recommendationETag computes the opaque tag of the recommendation for the request and the generation of the product details
+func recommendationETag(path string, query string, req recommender.ClusterRecommendationReq, generation time.Time) string {
+	normalized, _ := json.Marshal(req)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%d", path, query, normalized, generation.UnixNano())
+
+	return fmt.Sprintf(`"%x"`, hash.Sum(nil)[:16])
+}
+
+// weakETag marks the tag weak: the response is the same, but it's served gzip compressed or not depending on the request
+func weakETag(etag string) string {
+	return "W/" + etag
+}
+
+// etagMatches checks whether the If-None-Match header value lists the ETag, weak comparison is used
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
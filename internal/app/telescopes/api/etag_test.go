@@ -0,0 +1,183 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/banzaicloud/telescopes/internal/platform/buildinfo"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/gin-gonic/gin"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedGenerations reports the same generation for every region, if any
+type fixedGenerations struct {
+	generation time.Time
+}
+
+func (g *fixedGenerations) Generation(provider string, service string, region string) (time.Time, bool) {
+	return g.generation, !g.generation.IsZero()
+}
+
+// updatedProducts returns a single product with prices updated at the given time
+type updatedProducts struct {
+	updated time.Time
+}
+
+func (p *updatedProducts) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	return []recommender.VirtualMachine{{Type: "m5.xlarge", PricesUpdated: p.updated}}, nil
+}
+
+func (p *updatedProducts) GetRegions(provider, service string) ([]*models.Continent, error) {
+	return nil, nil
+}
+
+func TestRouteHandler_etagMiddleware(t *testing.T) {
+	const body = `{"sumCpu": 8, "sumMem": 32, "minNodes": 1, "maxNodes": 4}`
+	generation := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		generations *fixedGenerations
+		ifNoneMatch func(etag string) string
+		body        string
+		check       func(w *httptest.ResponseRecorder, recommended int)
+	}{
+		{
+			name:        "repeated request with matching etag not modified",
+			generations: &fixedGenerations{generation: generation},
+			ifNoneMatch: func(etag string) string { return etag },
+			body:        body,
+			check: func(w *httptest.ResponseRecorder, recommended int) {
+				assert.Equal(t, http.StatusNotModified, w.Code)
+				assert.True(t, strings.HasPrefix(w.Header().Get("ETag"), `W/"`), "the etag should be weak")
+				assert.Equal(t, 1, recommended)
+			},
+		},
+		{
+			name:        "equivalent request body matches",
+			generations: &fixedGenerations{generation: generation},
+			ifNoneMatch: func(etag string) string { return strings.TrimPrefix(etag, "W/") },
+			body:        `{"maxNodes":4,"minNodes":1,"sumMem":"32Gi","sumCpu":8}`,
+			check: func(w *httptest.ResponseRecorder, recommended int) {
+				assert.Equal(t, http.StatusNotModified, w.Code)
+				assert.Equal(t, 1, recommended)
+			},
+		},
+		{
+			name:        "different request recommended again",
+			generations: &fixedGenerations{generation: generation},
+			ifNoneMatch: func(etag string) string { return etag },
+			body:        `{"sumCpu": 16, "sumMem": 32, "minNodes": 1, "maxNodes": 4}`,
+			check: func(w *httptest.ResponseRecorder, recommended int) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.NotEmpty(t, w.Header().Get("ETag"))
+				assert.Equal(t, 2, recommended)
+			},
+		},
+		{
+			name:        "unknown generation not tagged",
+			generations: &fixedGenerations{},
+			ifNoneMatch: func(etag string) string { return `"any"` },
+			body:        body,
+			check: func(w *httptest.ResponseRecorder, recommended int) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Empty(t, w.Header().Get("ETag"))
+				assert.Equal(t, 2, recommended)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			routeHandler := NewRouteHandler(nil, buildinfo.New("0.1.0", "0a1b2c3", "2019-05-01T10:00:00Z"), nil, nil, logur.NewTestLogger())
+			routeHandler.EnableETags(test.generations, 0)
+
+			recommended := 0
+			router.POST("/provider/:provider/service/:service/region/:region/cluster", routeHandler.etagMiddleware, func(c *gin.Context) {
+				recommended++
+				c.JSON(http.StatusOK, gin.H{})
+			})
+			const path = "/provider/amazon/service/compute/region/eu-west-1/cluster"
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(test.body))
+			req.Header.Set("If-None-Match", test.ifNoneMatch(w.Header().Get("ETag")))
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			test.check(w, recommended)
+		})
+	}
+}
+
+func TestRouteHandler_etagMiddlewareUntagged(t *testing.T) {
+	generations := &fixedGenerations{generation: time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name    string
+		updated time.Time
+		body    string
+		status  int
+	}{
+		{
+			name:    "failed recommendation",
+			updated: time.Now(),
+			body:    `{"sumCpu": 8, "sumMem": 32, "minNodes": 1, "maxNodes": 4}`,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "stability objective depending on the spot advisor",
+			updated: time.Now(),
+			body:    `{"sumCpu": 8, "sumMem": 32, "minNodes": 1, "maxNodes": 4, "objective": "stability"}`,
+			status:  http.StatusOK,
+		},
+		{
+			name:    "stale prices",
+			updated: time.Now().Add(-2 * time.Hour),
+			body:    `{"sumCpu": 8, "sumMem": 32, "minNodes": 1, "maxNodes": 4}`,
+			status:  http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			routeHandler := NewRouteHandler(nil, buildinfo.New("0.1.0", "0a1b2c3", "2019-05-01T10:00:00Z"), nil, &updatedProducts{updated: test.updated}, logur.NewTestLogger())
+			routeHandler.EnableETags(generations, time.Hour)
+
+			router.POST("/provider/:provider/service/:service/region/:region/cluster", routeHandler.etagMiddleware, func(c *gin.Context) {
+				c.JSON(test.status, gin.H{})
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/provider/amazon/service/compute/region/eu-west-1/cluster", strings.NewReader(test.body)))
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Empty(t, w.Header().Get("ETag"))
+		})
+	}
+}
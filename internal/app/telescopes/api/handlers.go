@@ -15,17 +15,30 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/banzaicloud/telescopes/internal/platform/classifier"
 	"github.com/banzaicloud/telescopes/internal/platform/errorresponse"
 	"github.com/banzaicloud/telescopes/internal/platform/log"
 	"github.com/banzaicloud/telescopes/pkg/recommender"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/goph/emperror"
 	"github.com/mitchellh/mapstructure"
 )
 
+const (
+	// totalCountHeader carries the number of items available in a paginated listing
+	totalCountHeader = "X-Total-Count"
+	// defaultProductsLimit is the page size of the products listing when no limit is requested
+	defaultProductsLimit = 100
+)
+
 // swagger:route POST /recommender/provider/{provider}/service/{service}/region/{region}/cluster recommend recommendCluster
 //
 // Provides a recommended set of node pools on a given provider in a specific region.
@@ -35,6 +48,7 @@ import (
 //
 //     Produces:
 //     - application/json
+//     - text/csv
 //
 //     Schemes: http
 //
@@ -50,6 +64,7 @@ func (r *RouteHandler) recommendCluster() gin.HandlerFunc {
 			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to decode path parameters"))
 			return
 		}
+		pathParams.normalize()
 
 		logger := log.WithFieldsForHandlers(c, r.log,
 			map[string]interface{}{"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
@@ -61,6 +76,20 @@ func (r *RouteHandler) recommendCluster() gin.HandlerFunc {
 			return
 		}
 
+		queryParams := RecommendationQueryParams{}
+
+		if err := c.ShouldBindQuery(&queryParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.WrapWith(err, "failed to bind query parameters", classifier.ValidationErrTag))
+			return
+		}
+
+		vmFields, err := parseVmFields(queryParams.Fields)
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.With(err, classifier.ValidationErrTag))
+			return
+		}
+
 		// request decorated with provider and region - used to validate the request
 		req := recommender.ClusterRecommendationReq{}
 
@@ -70,12 +99,19 @@ func (r *RouteHandler) recommendCluster() gin.HandlerFunc {
 			return
 		}
 
-		if response, err := r.engine.RecommendCluster(pathParams.Provider, pathParams.Service, pathParams.Region, req, nil); err != nil {
+		response, err := recommendWithDeadline(c.Request.Context(), r.requestTimeout, func(ctx context.Context) (*recommender.ClusterRecommendationResp, error) {
+			return r.engine.WithLogger(logger).WithContext(ctx).RecommendCluster(pathParams.Provider, pathParams.Service, pathParams.Region, req, nil)
+		})
+		if err != nil {
 			errorresponse.NewErrorResponder(c).Respond(err)
 			return
-		} else {
-			c.JSON(http.StatusOK, RecommendationResponse{*response})
 		}
+		r.audit(pathParams, req, response)
+
+		if queryParams.Summary {
+			response.Summary = recommendationSummary(*response)
+		}
+		respondRecommendation(c, queryParams.Format, vmFields, response)
 	}
 }
 
@@ -88,6 +124,7 @@ func (r *RouteHandler) recommendCluster() gin.HandlerFunc {
 //
 //     Produces:
 //     - application/json
+//     - text/csv
 //
 //     Schemes: http
 //
@@ -103,6 +140,7 @@ func (r *RouteHandler) recommendClusterScaleOut() gin.HandlerFunc {
 			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to decode path parameters"))
 			return
 		}
+		pathParams.normalize()
 
 		logger := log.WithFieldsForHandlers(c, r.log,
 			map[string]interface{}{"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
@@ -114,6 +152,20 @@ func (r *RouteHandler) recommendClusterScaleOut() gin.HandlerFunc {
 			return
 		}
 
+		queryParams := RecommendationQueryParams{}
+
+		if err := c.ShouldBindQuery(&queryParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.WrapWith(err, "failed to bind query parameters", classifier.ValidationErrTag))
+			return
+		}
+
+		vmFields, err := parseVmFields(queryParams.Fields)
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.With(err, classifier.ValidationErrTag))
+			return
+		}
+
 		req := recommender.ClusterScaleoutRecommendationReq{}
 
 		if err := c.BindJSON(&req); err != nil {
@@ -122,15 +174,126 @@ func (r *RouteHandler) recommendClusterScaleOut() gin.HandlerFunc {
 			return
 		}
 
-		if response, err := r.engine.RecommendClusterScaleOut(pathParams.Provider, pathParams.Service, pathParams.Region, req); err != nil {
+		response, err := recommendWithDeadline(c.Request.Context(), r.requestTimeout, func(ctx context.Context) (*recommender.ClusterRecommendationResp, error) {
+			return r.engine.WithLogger(logger).WithContext(ctx).RecommendClusterScaleOut(pathParams.Provider, pathParams.Service, pathParams.Region, req)
+		})
+		if err != nil {
 			errorresponse.NewErrorResponder(c).Respond(err)
 			return
-		} else {
+		}
+		r.audit(pathParams, req, response)
+
+		if queryParams.Summary {
+			response.Summary = recommendationSummary(*response)
+		}
+		respondRecommendation(c, queryParams.Format, vmFields, response)
+	}
+}
+
+// respondRecommendation writes the recommendation to the response in the requested format
+// the virtual machines are projected to the requested fields in the json format
+func respondRecommendation(c *gin.Context, format string, vmFields []string, response *recommender.ClusterRecommendationResp) {
+	switch format {
+	case formatCsv:
+		var buf bytes.Buffer
+		if err := writeNodePoolsCsv(&buf, response.NodePools); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to render csv"))
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", csvFileName(*response)))
+		c.Data(http.StatusOK, "text/csv", buf.Bytes())
+	case formatEksctl:
+		var buf bytes.Buffer
+		if err := writeEksctlNodeGroups(&buf, *response); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.With(err, classifier.ValidationErrTag))
+			return
+		}
+		c.Data(http.StatusOK, "application/x-yaml", buf.Bytes())
+	case formatCapi:
+		var buf bytes.Buffer
+		if err := writeCapiMachineDeployments(&buf, *response); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.With(err, classifier.ValidationErrTag))
+			return
+		}
+		c.Data(http.StatusOK, "application/x-yaml", buf.Bytes())
+	default:
+		if len(vmFields) == 0 {
 			c.JSON(http.StatusOK, RecommendationResponse{*response})
+			return
+		}
+		projected, err := projectVmFields(RecommendationResponse{*response}, vmFields)
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to project the recommendation"))
+			return
 		}
+		c.JSON(http.StatusOK, projected)
 	}
 }
 
+// swagger:route POST /recommender/provider/{provider}/service/{service}/region/{region}/validate recommend validateClusterRecommendation
+//
+// Validates a cluster recommendation request by the same rules as the recommendation, without performing it.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: ValidationResponse
+//       400: ValidationResponse
+func (r *RouteHandler) validateClusterRecommendation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := GetRecommendationParams{}
+
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to decode path parameters"))
+			return
+		}
+		pathParams.normalize()
+
+		logger := log.WithFieldsForHandlers(c, r.log,
+			map[string]interface{}{"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+
+		logger.Info("validate cluster recommendation request")
+
+		if err := NewCloudInfoValidator(r.ciCli).Validate(pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		req := recommender.ClusterRecommendationReq{}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, invalidRequest(err))
+			return
+		}
+
+		if err := r.engine.WithLogger(logger).ValidateClusterRecommendationReq(req); err != nil {
+			c.JSON(http.StatusBadRequest, invalidRequest(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ValidationResponse{Valid: true})
+	}
+}
+
+// invalidRequest assembles the validation response of an invalid request, the validator reports one field error per line
+func invalidRequest(err error) ValidationResponse {
+	var errs []string
+	for _, e := range strings.Split(err.Error(), "\n") {
+		if e = strings.TrimSpace(e); e != "" {
+			errs = append(errs, e)
+		}
+	}
+	return ValidationResponse{Valid: false, Errors: errs}
+}
+
 // swagger:route POST /recommender/multicloud recommend recommendMultiCluster
 //
 // Provides a recommended set of node pools on a given provider in a specific region.
@@ -165,7 +328,7 @@ func (r *RouteHandler) recommendMultiCluster() gin.HandlerFunc {
 			return
 		}
 
-		if response, err := r.engine.RecommendMultiCluster(req); err != nil {
+		if response, err := r.engine.WithLogger(logger).WithContext(c.Request.Context()).RecommendMultiCluster(req); err != nil {
 			errorresponse.NewErrorResponder(c).Respond(err)
 			return
 		} else {
@@ -174,10 +337,563 @@ func (r *RouteHandler) recommendMultiCluster() gin.HandlerFunc {
 	}
 }
 
+// swagger:route POST /recommender/batch recommend recommendBatch
+//
+// Provides recommendations for a batch of requests, each of them on a given provider in a specific region.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: BatchRecommendationResponse
+func (r *RouteHandler) recommendBatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		logger := log.WithFieldsForHandlers(c, r.log, map[string]interface{}{})
+
+		logger.Info("recommend batch")
+
+		var items []BatchRecommendationItem
+		if err := c.BindJSON(&items); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.WrapWith(err, "failed to bind request body", classifier.ValidationErrTag))
+			return
+		}
+
+		if len(items) == 0 || len(items) > maxBatchSize {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.With(fmt.Errorf("the batch must contain 1 to %d items", maxBatchSize), classifier.ValidationErrTag))
+			return
+		}
+
+		ctx, cancel := withDeadline(c.Request.Context(), r.requestTimeout)
+		defer cancel()
+
+		engine := r.engine.WithLogger(logger)
+		results := runBatch(ctx, items, batchConcurrency, func(item BatchRecommendationItem) (*recommender.ClusterRecommendationResp, error) {
+			return recommendBefore(ctx, r.requestTimeout, func(ctx context.Context) (*recommender.ClusterRecommendationResp, error) {
+				return r.recommendBatchItem(engine.WithContext(ctx), item)
+			})
+		})
+
+		c.JSON(http.StatusOK, BatchRecommendationResponse{results})
+	}
+}
+
+// swagger:route POST /recommender/provider/{provider}/service/{service}/cheapest-region recommend recommendCheapestRegion
+//
+// Performs the cluster recommendation in each of the candidate regions of a provider's service and selects the cheapest one.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: CheapestRegionResponse
+func (r *RouteHandler) recommendCheapestRegion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provider, service := strings.ToLower(c.Param("provider")), strings.ToLower(c.Param("service"))
+
+		logger := log.WithFieldsForHandlers(c, r.log, map[string]interface{}{"provider": provider, "service": service})
+
+		logger.Info("recommend cheapest region")
+
+		req := CheapestRegionRequest{}
+		if err := c.BindJSON(&req); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.WrapWith(err, "failed to bind request body", classifier.ValidationErrTag))
+			return
+		}
+
+		ctx, cancel := withDeadline(c.Request.Context(), r.requestTimeout)
+		defer cancel()
+
+		engine := r.engine.WithLogger(logger)
+		results := runBatch(ctx, regionItems(provider, service, req), batchConcurrency, func(item BatchRecommendationItem) (*recommender.ClusterRecommendationResp, error) {
+			return recommendBefore(ctx, r.requestTimeout, func(ctx context.Context) (*recommender.ClusterRecommendationResp, error) {
+				return r.recommendBatchItem(engine.WithContext(ctx), item)
+			})
+		})
+
+		response, err := cheapestRegion(results)
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// swagger:route POST /recommender/provider/{provider}/service/{service}/region/{region}/savings recommend estimateSavings
+//
+// Estimates the savings of a spot only cluster compared to an on-demand only one on a given provider in a specific region.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: SavingsResponse
+func (r *RouteHandler) estimateSavings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := GetRecommendationParams{}
+
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to decode path parameters"))
+			return
+		}
+		pathParams.normalize()
+
+		logger := log.WithFieldsForHandlers(c, r.log,
+			map[string]interface{}{"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+
+		logger.Info("estimate spot savings")
+
+		if err := NewCloudInfoValidator(r.ciCli).Validate(pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		req := recommender.ClusterRecommendationReq{}
+
+		if err := c.BindJSON(&req); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.WrapWith(err, "failed to bind request body", classifier.ValidationErrTag))
+			return
+		}
+
+		// both recommendations complete within a single request timeout
+		ctx, cancel := withDeadline(c.Request.Context(), r.requestTimeout)
+		defer cancel()
+
+		engine := r.engine.WithLogger(logger)
+		onDemandReq, spotReq := savingsRequests(req)
+
+		onDemand, err := recommendBefore(ctx, r.requestTimeout, func(ctx context.Context) (*recommender.ClusterRecommendationResp, error) {
+			return engine.WithContext(ctx).RecommendCluster(pathParams.Provider, pathParams.Service, pathParams.Region, onDemandReq, nil)
+		})
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		spot, err := recommendBefore(ctx, r.requestTimeout, func(ctx context.Context) (*recommender.ClusterRecommendationResp, error) {
+			return engine.WithContext(ctx).RecommendCluster(pathParams.Provider, pathParams.Service, pathParams.Region, spotReq, nil)
+		})
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, estimateSavings(onDemand, spot))
+	}
+}
+
+// swagger:route POST /recommender/provider/{provider}/service/{service}/region/{region}/diff recommend diffRecommendation
+//
+// Compares the recommendation on a given provider in a specific region to a previously returned one.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: DiffResponse
+func (r *RouteHandler) diffRecommendation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := GetRecommendationParams{}
+
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to decode path parameters"))
+			return
+		}
+		pathParams.normalize()
+
+		logger := log.WithFieldsForHandlers(c, r.log,
+			map[string]interface{}{"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+
+		logger.Info("diff recommendation")
+
+		if err := NewCloudInfoValidator(r.ciCli).Validate(pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		req := DiffRequest{}
+
+		if err := c.BindJSON(&req); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.WrapWith(err, "failed to bind request body", classifier.ValidationErrTag))
+			return
+		}
+
+		engine := r.engine.WithLogger(logger)
+		current, err := recommendWithDeadline(c.Request.Context(), r.requestTimeout, func(ctx context.Context) (*recommender.ClusterRecommendationResp, error) {
+			return engine.WithContext(ctx).RecommendCluster(pathParams.Provider, pathParams.Service, pathParams.Region, req.Request, nil)
+		})
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		diff, err := diffRecommendations(&req.Previous, current)
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.With(err, classifier.ValidationErrTag))
+			return
+		}
+
+		c.JSON(http.StatusOK, diff)
+	}
+}
+
+// recommendBatchItem validates and performs the recommendation of a single batch item
+func (r *RouteHandler) recommendBatchItem(engine recommender.ClusterRecommender, item BatchRecommendationItem) (*recommender.ClusterRecommendationResp, error) {
+	pathParams := GetRecommendationParams{Provider: item.Provider, Service: item.Service, Region: item.Region}
+	pathParams.normalize()
+
+	if err := NewCloudInfoValidator(r.ciCli).Validate(pathParams); err != nil {
+		return nil, err
+	}
+
+	if err := binding.Validator.ValidateStruct(item.Request); err != nil {
+		return nil, emperror.WrapWith(err, "invalid request", classifier.ValidationErrTag)
+	}
+
+	response, err := engine.RecommendCluster(pathParams.Provider, pathParams.Service, pathParams.Region, item.Request, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.audit(pathParams, item.Request, response)
+
+	return response, nil
+}
+
+// swagger:route GET /recommender/provider/{provider}/service/{service}/region/{region}/products products getProducts
+//
+// Lists the instance types available on a given provider in a specific region.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: ProductsResponse
+func (r *RouteHandler) getProducts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := GetRecommendationParams{}
+
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to decode path parameters"))
+			return
+		}
+		pathParams.normalize()
+
+		logger := log.WithFieldsForHandlers(c, r.log,
+			map[string]interface{}{"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+
+		logger.Info("list products")
+
+		if err := NewCloudInfoValidator(r.ciCli).Validate(pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		queryParams := GetProductsQueryParams{}
+
+		if err := c.ShouldBindQuery(&queryParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.WrapWith(err, "failed to bind query parameters", classifier.ValidationErrTag))
+			return
+		}
+
+		products, err := recommender.ProductDetails(c.Request.Context(), r.ciSource, pathParams.Provider, pathParams.Service, pathParams.Region)
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		filtered := filterProducts(products, queryParams)
+		sortProducts(filtered, queryParams.Sort, queryParams.Order)
+
+		page := paginateProducts(filtered, queryParams.Limit, queryParams.Offset)
+		for i := range page {
+			page[i] = recommender.WithNormalizedPrices(page[i])
+		}
+
+		c.Header(totalCountHeader, strconv.Itoa(len(filtered)))
+		c.JSON(http.StatusOK, ProductsResponse{page})
+	}
+}
+
+// swagger:route GET /recommender/provider/{provider}/service/{service}/region/{region}/cheapest products getCheapestProduct
+//
+// Provides the cheapest instance type with at least the requested resources on a given provider in a specific region.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: VirtualMachine
+func (r *RouteHandler) getCheapestProduct() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := GetRecommendationParams{}
+
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to decode path parameters"))
+			return
+		}
+		pathParams.normalize()
+
+		logger := log.WithFieldsForHandlers(c, r.log,
+			map[string]interface{}{"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+
+		logger.Info("find cheapest product")
+
+		if err := NewCloudInfoValidator(r.ciCli).Validate(pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		queryParams := GetCheapestProductQueryParams{}
+
+		if err := c.ShouldBindQuery(&queryParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.WrapWith(err, "failed to bind query parameters", classifier.ValidationErrTag))
+			return
+		}
+
+		products, err := recommender.ProductDetails(c.Request.Context(), r.ciSource, pathParams.Provider, pathParams.Service, pathParams.Region)
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		candidates := filterProducts(products, GetProductsQueryParams{MinCpu: queryParams.Cpu, MinMem: queryParams.Mem})
+		cheapest, ok := cheapestProduct(candidates, queryParams.VmClass)
+		if !ok {
+			errorresponse.NewErrorResponder(c).Respond(emperror.With(
+				fmt.Errorf("no instance type found with at least %v cpus and %v GB memory", queryParams.Cpu, queryParams.Mem),
+				classifier.NotFoundErrTag))
+			return
+		}
+
+		c.JSON(http.StatusOK, recommender.WithNormalizedPrices(cheapest))
+	}
+}
+
+// swagger:route GET /recommender/provider/{provider}/service/{service}/region/{region}/compare products compareProducts
+//
+// Compares the attributes and prices of two instance types on a given provider in a specific region.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: CompareProductsResponse
+func (r *RouteHandler) getProductComparison() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pathParams := GetRecommendationParams{}
+
+		if err := mapstructure.Decode(getPathParamMap(c), &pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(emperror.Wrap(err, "failed to decode path parameters"))
+			return
+		}
+		pathParams.normalize()
+
+		logger := log.WithFieldsForHandlers(c, r.log,
+			map[string]interface{}{"provider": pathParams.Provider, "service": pathParams.Service, "region": pathParams.Region})
+
+		logger.Info("compare products")
+
+		if err := NewCloudInfoValidator(r.ciCli).Validate(pathParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		queryParams := CompareProductsQueryParams{}
+
+		if err := c.ShouldBindQuery(&queryParams); err != nil {
+			errorresponse.NewErrorResponder(c).Respond(
+				emperror.WrapWith(err, "failed to bind query parameters", classifier.ValidationErrTag))
+			return
+		}
+
+		products, err := recommender.ProductDetails(c.Request.Context(), r.ciSource, pathParams.Provider, pathParams.Service, pathParams.Region)
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		comparison, err := compareProducts(products, queryParams.A, queryParams.B)
+		if err != nil {
+			errorresponse.NewErrorResponder(c).Respond(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, comparison)
+	}
+}
+
+// compareProducts looks up the two instance types in the products and puts their attributes and prices side by side
+func compareProducts(products []recommender.VirtualMachine, a, b string) (CompareProductsResponse, error) {
+	var (
+		comparison CompareProductsResponse
+		foundA     bool
+		foundB     bool
+	)
+	for _, p := range products {
+		if p.Type == a {
+			comparison.A, foundA = productComparison(p), true
+		}
+		if p.Type == b {
+			comparison.B, foundB = productComparison(p), true
+		}
+	}
+
+	for _, unknown := range []struct {
+		vmType string
+		found  bool
+	}{{a, foundA}, {b, foundB}} {
+		if !unknown.found {
+			return CompareProductsResponse{}, emperror.With(
+				fmt.Errorf("unknown instance type in the region: %s", unknown.vmType), classifier.ValidationErrTag)
+		}
+	}
+
+	return comparison, nil
+}
+
+// productComparison collects the compared attributes and prices of the product
+func productComparison(p recommender.VirtualMachine) ProductComparison {
+	return ProductComparison{
+		Type:          p.Type,
+		Cpus:          p.Cpus,
+		Mem:           p.Mem,
+		Gpus:          p.Gpus,
+		OnDemandPrice: p.OnDemandPrice,
+		SpotPrice:     p.AvgPrice,
+		SpotDiscount:  spotDiscount(p),
+	}
+}
+
+// cheapestProduct returns the product with the lowest price of the vm class, products without a price are skipped
+func cheapestProduct(products []recommender.VirtualMachine, vmClass string) (recommender.VirtualMachine, bool) {
+	var (
+		cheapest recommender.VirtualMachine
+		found    bool
+	)
+	for _, p := range products {
+		price := productPrice(p, vmClass)
+		if price == 0 {
+			continue
+		}
+		if !found || price < productPrice(cheapest, vmClass) {
+			cheapest, found = p, true
+		}
+	}
+	return cheapest, found
+}
+
+// productPrice returns the price of the product for the vm class
+func productPrice(p recommender.VirtualMachine, vmClass string) float64 {
+	if vmClass == recommender.Spot {
+		return p.AvgPrice
+	}
+	return p.OnDemandPrice
+}
+
+// paginateProducts returns the page of products selected by the limit and offset, an offset past the end results in an empty page
+func paginateProducts(products []recommender.VirtualMachine, limit, offset int) []recommender.VirtualMachine {
+	if limit == 0 {
+		limit = defaultProductsLimit
+	}
+	if offset >= len(products) {
+		return []recommender.VirtualMachine{}
+	}
+	end := offset + limit
+	if end > len(products) {
+		end = len(products)
+	}
+	return products[offset:end]
+}
+
+// filterProducts retains the products that satisfy the query parameters
+func filterProducts(products []recommender.VirtualMachine, queryParams GetProductsQueryParams) []recommender.VirtualMachine {
+	filtered := make([]recommender.VirtualMachine, 0, len(products))
+	for _, p := range products {
+		if p.Cpus >= queryParams.MinCpu && p.Mem >= queryParams.MinMem {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// swagger:route GET /info info getInfo
+//
+// Describes the request options supported on the known providers and the health of the requested ones.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http
+//
+//     Security:
+//
+//     Responses:
+//       200: InfoResponse
+func (r *RouteHandler) infoHandler(c *gin.Context) {
+	info := InfoResponse{Providers: r.engine.Capabilities()}
+	if r.health != nil {
+		info.Health = r.health.Statuses()
+	}
+	c.JSON(http.StatusOK, info)
+}
+
 func (r *RouteHandler) versionHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, r.buildInfo)
 }
 
+// openApiSpecHandler serves the OpenAPI spec describing the request and response bodies
+func (r *RouteHandler) openApiSpecHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, openApiSpec(r.buildInfo.Version))
+}
+
 // getPathParamMap transforms the path params into a map to be able to easily bind to param structs
 func getPathParamMap(c *gin.Context) map[string]string {
 	pm := make(map[string]string)
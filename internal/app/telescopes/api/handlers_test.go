@@ -0,0 +1,256 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/telescopes/internal/platform/classifier"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/emperror"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var products = []recommender.VirtualMachine{
+	{
+		Type: "c5.large",
+		Cpus: 2,
+		Mem:  4,
+	},
+	{
+		Type: "m5.xlarge",
+		Cpus: 4,
+		Mem:  16,
+	},
+	{
+		Type: "r5.xlarge",
+		Cpus: 4,
+		Mem:  32,
+	},
+}
+
+func Test_filterProducts(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryParams GetProductsQueryParams
+		check       func(vms []recommender.VirtualMachine)
+	}{
+		{
+			name:        "no filters",
+			queryParams: GetProductsQueryParams{},
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, 3, len(vms))
+			},
+		},
+		{
+			name:        "min cpu and memory",
+			queryParams: GetProductsQueryParams{MinCpu: 4, MinMem: 17},
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, 1, len(vms))
+				assert.Equal(t, "r5.xlarge", vms[0].Type)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(filterProducts(products, test.queryParams))
+		})
+	}
+}
+
+func Test_paginateProducts(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		check  func(vms []recommender.VirtualMachine)
+	}{
+		{
+			name:   "first page",
+			limit:  2,
+			offset: 0,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, 2, len(vms))
+				assert.Equal(t, "c5.large", vms[0].Type)
+				assert.Equal(t, "m5.xlarge", vms[1].Type)
+			},
+		},
+		{
+			name:   "middle page",
+			limit:  1,
+			offset: 1,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, 1, len(vms))
+				assert.Equal(t, "m5.xlarge", vms[0].Type)
+			},
+		},
+		{
+			name:   "last page shorter than the limit",
+			limit:  2,
+			offset: 2,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, 1, len(vms))
+				assert.Equal(t, "r5.xlarge", vms[0].Type)
+			},
+		},
+		{
+			name:   "default limit",
+			limit:  0,
+			offset: 0,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, 3, len(vms))
+			},
+		},
+		{
+			name:   "out of range offset results in an empty page",
+			limit:  2,
+			offset: 10,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.NotNil(t, vms)
+				assert.Equal(t, 0, len(vms))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(paginateProducts(products, test.limit, test.offset))
+		})
+	}
+}
+
+func Test_cheapestProduct(t *testing.T) {
+	products := []recommender.VirtualMachine{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.08},
+		{Type: "m4.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.2, AvgPrice: 0.06},
+		{Type: "r5.xlarge", Cpus: 4, Mem: 32, OnDemandPrice: 0.252, AvgPrice: 0},
+	}
+	tests := []struct {
+		name    string
+		spec    GetProductsQueryParams
+		vmClass string
+		check   func(vm recommender.VirtualMachine, found bool)
+	}{
+		{
+			name:    "cheapest on-demand instance type for the spec",
+			spec:    GetProductsQueryParams{MinCpu: 4, MinMem: 16},
+			vmClass: "",
+			check: func(vm recommender.VirtualMachine, found bool) {
+				assert.True(t, found)
+				assert.Equal(t, "m5.xlarge", vm.Type)
+			},
+		},
+		{
+			name:    "cheapest spot instance type for the spec",
+			spec:    GetProductsQueryParams{MinCpu: 4, MinMem: 16},
+			vmClass: recommender.Spot,
+			check: func(vm recommender.VirtualMachine, found bool) {
+				assert.True(t, found)
+				assert.Equal(t, "m4.xlarge", vm.Type)
+			},
+		},
+		{
+			name:    "instance types without spot price are skipped",
+			spec:    GetProductsQueryParams{MinCpu: 4, MinMem: 32},
+			vmClass: recommender.Spot,
+			check: func(vm recommender.VirtualMachine, found bool) {
+				assert.False(t, found)
+			},
+		},
+		{
+			name:    "impossible spec",
+			spec:    GetProductsQueryParams{MinCpu: 128, MinMem: 16},
+			vmClass: "",
+			check: func(vm recommender.VirtualMachine, found bool) {
+				assert.False(t, found)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(cheapestProduct(filterProducts(products, test.spec), test.vmClass))
+		})
+	}
+}
+
+func Test_compareProducts(t *testing.T) {
+	products := []recommender.VirtualMachine{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.2, AvgPrice: 0.08},
+		{Type: "c5.xlarge", Cpus: 4, Mem: 8, OnDemandPrice: 0.17, AvgPrice: 0},
+	}
+	tests := []struct {
+		name  string
+		a     string
+		b     string
+		check func(comparison CompareProductsResponse, err error)
+	}{
+		{
+			name: "instance types compared side by side",
+			a:    "m5.xlarge",
+			b:    "c5.xlarge",
+			check: func(comparison CompareProductsResponse, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, ProductComparison{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.2, SpotPrice: 0.08, SpotDiscount: 60}, comparison.A)
+				assert.Equal(t, ProductComparison{Type: "c5.xlarge", Cpus: 4, Mem: 8, OnDemandPrice: 0.17}, comparison.B)
+			},
+		},
+		{
+			name: "unknown instance type",
+			a:    "m5.xlarge",
+			b:    "x9.huge",
+			check: func(comparison CompareProductsResponse, err error) {
+				assert.EqualError(t, err, "unknown instance type in the region: x9.huge")
+				assert.Contains(t, emperror.Context(err), classifier.ValidationErrTag)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(compareProducts(products, test.a, test.b))
+		})
+	}
+}
+
+func Test_invalidRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		check func(resp ValidationResponse)
+	}{
+		{
+			name: "one error per field",
+			err: errors.New("Key: 'ClusterRecommendationReq.SumCpu' Error:Field validation for 'SumCpu' failed on the 'min' tag\n" +
+				"Key: 'ClusterRecommendationReq.OnDemandPct' Error:Field validation for 'OnDemandPct' failed on the 'max' tag"),
+			check: func(resp ValidationResponse) {
+				assert.False(t, resp.Valid)
+				assert.Equal(t, 2, len(resp.Errors))
+				assert.Contains(t, resp.Errors[1], "OnDemandPct")
+			},
+		},
+		{
+			name: "recommendation rule violated",
+			err:  errors.New("minVcpu (8) is greater than maxVcpu (4)"),
+			check: func(resp ValidationResponse) {
+				assert.False(t, resp.Valid)
+				assert.Equal(t, []string{"minVcpu (8) is greater than maxVcpu (4)"}, resp.Errors)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(invalidRequest(test.err))
+		})
+	}
+}
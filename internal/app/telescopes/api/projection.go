@@ -0,0 +1,90 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/pkg/errors"
+)
+
+// vmFieldNames holds the json names of the virtual machine fields that can be projected
+var vmFieldNames = jsonFieldNames(reflect.TypeOf(recommender.VirtualMachine{}))
+
+// jsonFieldNames collects the json names of the fields of the struct type
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// parseVmFields splits the comma separated list of virtual machine fields, unknown fields result in an error
+func parseVmFields(fields string) ([]string, error) {
+	if fields == "" {
+		return nil, nil
+	}
+
+	var vmFields []string
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if !vmFieldNames[field] {
+			return nil, errors.Errorf("unknown field: %s", field)
+		}
+		vmFields = append(vmFields, field)
+	}
+	return vmFields, nil
+}
+
+// projectVmFields transforms the recommendation to a generic structure where the
+// virtual machines of the node pools only hold the requested fields
+func projectVmFields(response RecommendationResponse, vmFields []string) (map[string]interface{}, error) {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the recommendation")
+	}
+
+	var projected map[string]interface{}
+	if err := json.Unmarshal(raw, &projected); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the recommendation")
+	}
+
+	nodePools, _ := projected["nodePools"].([]interface{})
+	for _, np := range nodePools {
+		nodePool, ok := np.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		vm, ok := nodePool["vm"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		projectedVm := make(map[string]interface{}, len(vmFields))
+		for _, field := range vmFields {
+			if value, ok := vm[field]; ok {
+				projectedVm[field] = value
+			}
+		}
+		nodePool["vm"] = projectedVm
+	}
+
+	return projected, nil
+}
@@ -0,0 +1,82 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseVmFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+		check  func(vmFields []string, err error)
+	}{
+		{
+			name:   "no projection",
+			fields: "",
+			check: func(vmFields []string, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Nil(t, vmFields)
+			},
+		},
+		{
+			name:   "known fields",
+			fields: "type, avgPrice",
+			check: func(vmFields []string, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"type", "avgPrice"}, vmFields)
+			},
+		},
+		{
+			name:   "unknown field",
+			fields: "type,price",
+			check: func(vmFields []string, err error) {
+				assert.EqualError(t, err, "unknown field: price")
+				assert.Nil(t, vmFields)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(parseVmFields(test.fields))
+		})
+	}
+}
+
+func Test_projectVmFields(t *testing.T) {
+	response := RecommendationResponse{recommender.ClusterRecommendationResp{
+		Provider: "amazon",
+		NodePools: []recommender.NodePool{
+			{
+				VmType:   recommender.VirtualMachine{Type: "m5.xlarge", AvgPrice: 0.07, OnDemandPrice: 0.192, Cpus: 4, Mem: 16},
+				SumNodes: 2,
+				VmClass:  recommender.Spot,
+			},
+		},
+	}}
+
+	projected, err := projectVmFields(response, []string{"type", "avgPrice"})
+
+	assert.Nil(t, err, "the error should be nil")
+	assert.Equal(t, "amazon", projected["provider"])
+
+	nodePool := projected["nodePools"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(2), nodePool["sumNodes"])
+	assert.Equal(t, map[string]interface{}{"type": "m5.xlarge", "avgPrice": 0.07}, nodePool["vm"])
+}
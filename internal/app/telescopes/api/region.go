@@ -0,0 +1,57 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/emperror"
+	"github.com/pkg/errors"
+)
+
+// regionItems creates a batch item of the request for each of the candidate regions
+func regionItems(provider, service string, req CheapestRegionRequest) []BatchRecommendationItem {
+	items := make([]BatchRecommendationItem, 0, len(req.Regions))
+	for _, region := range req.Regions {
+		items = append(items, BatchRecommendationItem{Provider: provider, Service: service, Region: region, Request: req.Request})
+	}
+	return items
+}
+
+// cheapestRegion selects the region with the lowest total price among the successful recommendations
+// the first one wins among equally priced regions; the error lists the reasons if there's no successful recommendation
+func cheapestRegion(results []BatchRecommendationResult) (CheapestRegionResponse, error) {
+	response := CheapestRegionResponse{Regions: results}
+
+	var failures []string
+	for _, result := range results {
+		if result.Response == nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Region, result.Error))
+			continue
+		}
+		if response.Response == nil || result.Response.Accuracy.RecTotalPrice < response.Response.Accuracy.RecTotalPrice {
+			response.Region, response.Response = result.Region, result.Response
+		}
+	}
+
+	if response.Response == nil {
+		return CheapestRegionResponse{}, emperror.With(
+			errors.Errorf("could not recommend a cluster in any of the regions: %s", strings.Join(failures, "; ")),
+			recommender.RecommenderErrorTag)
+	}
+	return response, nil
+}
@@ -0,0 +1,98 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/emperror"
+	"github.com/stretchr/testify/assert"
+)
+
+// pricedRecommend recommends clusters with region dependent prices
+func pricedRecommend(prices map[string]float64) recommendFunc {
+	return func(item BatchRecommendationItem) (*recommender.ClusterRecommendationResp, error) {
+		price, ok := prices[item.Region]
+		if !ok {
+			return nil, errors.New("region not found: " + item.Region)
+		}
+		return &recommender.ClusterRecommendationResp{
+			Provider: item.Provider,
+			Service:  item.Service,
+			Region:   item.Region,
+			Accuracy: recommender.ClusterRecommendationAccuracy{RecTotalPrice: price},
+		}, nil
+	}
+}
+
+func Test_cheapestRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []string
+		prices  map[string]float64
+		check   func(resp CheapestRegionResponse, err error)
+	}{
+		{
+			name:    "the cheaper region is selected",
+			regions: []string{"eu-west-1", "us-east-1"},
+			prices:  map[string]float64{"eu-west-1": 1.2, "us-east-1": 0.9},
+			check: func(resp CheapestRegionResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "us-east-1", resp.Region)
+				assert.Equal(t, 0.9, resp.Response.Accuracy.RecTotalPrice)
+				assert.Equal(t, 2, len(resp.Regions))
+				assert.Equal(t, "eu-west-1", resp.Regions[0].Region)
+				assert.Equal(t, "us-east-1", resp.Regions[1].Region)
+			},
+		},
+		{
+			name:    "failed regions are skipped",
+			regions: []string{"unknown", "eu-west-1"},
+			prices:  map[string]float64{"eu-west-1": 1.2},
+			check: func(resp CheapestRegionResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "eu-west-1", resp.Region)
+				assert.Equal(t, "region not found: unknown", resp.Regions[0].Error)
+			},
+		},
+		{
+			name:    "the first one of equally priced regions is selected",
+			regions: []string{"eu-central-1", "eu-west-1"},
+			prices:  map[string]float64{"eu-west-1": 1.2, "eu-central-1": 1.2},
+			check: func(resp CheapestRegionResponse, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "eu-central-1", resp.Region)
+			},
+		},
+		{
+			name:    "no recommendation in any of the regions",
+			regions: []string{"unknown"},
+			prices:  map[string]float64{},
+			check: func(resp CheapestRegionResponse, err error) {
+				assert.EqualError(t, err, "could not recommend a cluster in any of the regions: unknown: region not found: unknown")
+				assert.Contains(t, emperror.Context(err), recommender.RecommenderErrorTag)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items := regionItems("amazon", "compute", CheapestRegionRequest{Regions: test.regions})
+			test.check(cheapestRegion(runBatch(context.Background(), items, batchConcurrency, pricedRecommend(test.prices))))
+		})
+	}
+}
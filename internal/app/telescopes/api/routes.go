@@ -15,14 +15,23 @@
 package api
 
 import (
+	"math"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/banzaicloud/bank-vaults/pkg/auth"
 	"github.com/banzaicloud/go-gin-prometheus"
+	"github.com/banzaicloud/telescopes/internal/app/telescopes/audit"
 	"github.com/banzaicloud/telescopes/internal/platform/buildinfo"
+	"github.com/banzaicloud/telescopes/internal/platform/errorresponse"
+	"github.com/banzaicloud/telescopes/internal/platform/gzip"
 	"github.com/banzaicloud/telescopes/internal/platform/log"
+	"github.com/banzaicloud/telescopes/internal/platform/ratelimit"
 	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/banzaicloud/telescopes/pkg/recommender/providerhealth"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/goph/logur"
@@ -31,40 +40,142 @@ import (
 const (
 	// environment variable name to override base path if necessary
 	appBasePath = "TELESCOPES_BASEPATH"
+	// responses smaller than this (bytes) are not compressed
+	gzipMinLength = 1024
+	// header carrying the request ID, the log lines of a request are correlated by it
+	requestIdHeader = "X-Request-ID"
+	// environment variable names to restrict the CORS origins, methods and headers (comma separated lists)
+	corsOrigins = "TELESCOPES_CORS_ORIGINS"
+	corsMethods = "TELESCOPES_CORS_METHODS"
+	corsHeaders = "TELESCOPES_CORS_HEADERS"
+	// environment variable name to set a hard deadline for a whole recommendation (eg. 30s), there's no deadline if not set
+	requestTimeout = "TELESCOPES_REQUEST_TIMEOUT"
+	// environment variable names to limit the recommendation requests per client: requests per second and burst size
+	// there's no limit if the rate is not set, the burst defaults to the rate (at least 1)
+	rateLimit = "TELESCOPES_RATE_LIMIT"
+	rateBurst = "TELESCOPES_RATE_BURST"
 )
 
 // RouteHandler struct that wraps the recommender engine
 type RouteHandler struct {
-	engine    recommender.ClusterRecommender
-	buildInfo buildinfo.BuildInfo
-	ciCli     *recommender.CloudInfoClient
-	log       logur.Logger
+	engine         recommender.ClusterRecommender
+	buildInfo      buildinfo.BuildInfo
+	ciCli          *recommender.CloudInfoClient
+	ciSource       recommender.CloudInfoSource
+	log            logur.Logger
+	startTime      time.Time
+	requestTimeout time.Duration
+	auditor        *audit.Webhook
+	health         *providerhealth.Source
+	generations    GenerationSource
+	maxPriceAge    time.Duration
 }
 
 // NewRouteHandler creates a new RouteHandler and returns a reference to it
-func NewRouteHandler(engine *recommender.Engine, info buildinfo.BuildInfo, ciCli *recommender.CloudInfoClient, log logur.Logger) *RouteHandler {
+// the product details are looked up from the source (eg. the cached, throttled source the engine uses), the paths are validated by the client
+func NewRouteHandler(engine *recommender.Engine, info buildinfo.BuildInfo, ciCli *recommender.CloudInfoClient, ciSource recommender.CloudInfoSource, log logur.Logger) *RouteHandler {
 	return &RouteHandler{
-		engine:    engine,
-		buildInfo: info,
-		ciCli:     ciCli,
-		log:       log,
+		engine:         engine,
+		buildInfo:      info,
+		ciCli:          ciCli,
+		ciSource:       ciSource,
+		log:            log,
+		startTime:      time.Now(),
+		requestTimeout: durationFromEnv(requestTimeout, log),
 	}
 }
 
+// getCorsConfig assembles the CORS configuration, all origins are allowed unless restricted from the environment
 func getCorsConfig() cors.Config {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	if !config.AllowAllOrigins {
-		config.AllowOrigins = []string{"http://", "https://"}
+	if origins := listFromEnv(corsOrigins); len(origins) > 0 {
+		config.AllowAllOrigins = false
+		config.AllowOrigins = origins
 	}
 	config.AllowMethods = []string{http.MethodPut, http.MethodDelete, http.MethodGet, http.MethodPost, http.MethodOptions}
+	if methods := listFromEnv(corsMethods); len(methods) > 0 {
+		config.AllowMethods = methods
+	}
 	config.AllowHeaders = []string{"Origin", "Authorization", "Content-Type"}
+	if headers := listFromEnv(corsHeaders); len(headers) > 0 {
+		config.AllowHeaders = headers
+	}
 	config.ExposeHeaders = []string{"Content-Length"}
 	config.AllowCredentials = true
 	config.MaxAge = 12
 	return config
 }
 
+// listFromEnv parses the comma separated list in the given environment variable, empty items are dropped
+func listFromEnv(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// durationFromEnv parses the duration in the given environment variable, an invalid value is ignored
+func durationFromEnv(key string, log logur.Logger) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Warn("invalid duration ignored", map[string]interface{}{"variable": key, "value": value})
+		return 0
+	}
+	return duration
+}
+
+// floatFromEnv parses the number in the given environment variable, an invalid value is ignored
+func floatFromEnv(key string, log logur.Logger) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		log.Warn("invalid number ignored", map[string]interface{}{"variable": key, "value": value})
+		return 0
+	}
+	return f
+}
+
+// rateLimitMiddleware returns the rate limiter of the recommendation requests if a rate limit is configured
+func (r *RouteHandler) rateLimitMiddleware() []gin.HandlerFunc {
+	limit := floatFromEnv(rateLimit, r.log)
+	if limit == 0 {
+		return nil
+	}
+
+	burst := int(floatFromEnv(rateBurst, r.log))
+	if burst == 0 {
+		burst = int(math.Max(1, math.Ceil(limit)))
+	}
+
+	r.log.Info("recommendation requests are rate limited", map[string]interface{}{"rate": limit, "burst": burst})
+	return []gin.HandlerFunc{ratelimit.Middleware(limit, burst)}
+}
+
+// providerHealthMiddleware rejects the requests to unhealthy providers before they reach the Cloud Info service
+// the requests without a provider in the path are checked when the product details are retrieved
+func (r *RouteHandler) providerHealthMiddleware(c *gin.Context) {
+	if r.health == nil || c.Param("provider") == "" {
+		return
+	}
+	if err := r.health.Check(strings.ToLower(c.Param("provider"))); err != nil {
+		errorresponse.NewErrorResponder(c).Respond(err)
+		c.Abort()
+	}
+}
+
 // ConfigureRoutes configures the gin engine, defines the rest API for this application
 func (r *RouteHandler) ConfigureRoutes(router *gin.Engine) {
 	r.log.Info("configuring routes")
@@ -75,9 +186,10 @@ func (r *RouteHandler) ConfigureRoutes(router *gin.Engine) {
 		basePath = basePathFromEnv
 	}
 
-	router.Use(log.MiddlewareCorrelationId())
+	router.Use(log.MiddlewareCorrelationId(log.Header(requestIdHeader)))
 	router.Use(log.Middleware())
 	router.Use(cors.New(getCorsConfig()))
+	router.Use(gzip.Middleware(gzipMinLength))
 
 	base := router.Group(basePath)
 	{
@@ -86,12 +198,22 @@ func (r *RouteHandler) ConfigureRoutes(router *gin.Engine) {
 	}
 
 	v1 := base.Group("/api/v1")
+	v1.GET("/openapi.json", r.openApiSpecHandler)
+	v1.GET("/info", r.infoHandler)
 
-	recGroup := v1.Group("/recommender")
+	recGroup := v1.Group("/recommender", append(r.rateLimitMiddleware(), r.providerHealthMiddleware)...)
 	{
 		recGroup.POST("/multicloud", r.recommendMultiCluster())
-		recGroup.POST("/provider/:provider/service/:service/region/:region/cluster", r.recommendCluster())
+		recGroup.POST("/batch", r.recommendBatch())
+		recGroup.POST("/provider/:provider/service/:service/cheapest-region", r.recommendCheapestRegion())
+		recGroup.POST("/provider/:provider/service/:service/region/:region/savings", r.estimateSavings())
+		recGroup.POST("/provider/:provider/service/:service/region/:region/diff", r.diffRecommendation())
+		recGroup.POST("/provider/:provider/service/:service/region/:region/cluster", r.etagMiddleware, r.recommendCluster())
 		recGroup.PUT("/provider/:provider/service/:service/region/:region/cluster", r.recommendClusterScaleOut())
+		recGroup.POST("/provider/:provider/service/:service/region/:region/validate", r.validateClusterRecommendation())
+		recGroup.GET("/provider/:provider/service/:service/region/:region/products", r.getProducts())
+		recGroup.GET("/provider/:provider/service/:service/region/:region/cheapest", r.getCheapestProduct())
+		recGroup.GET("/provider/:provider/service/:service/region/:region/compare", r.getProductComparison())
 	}
 }
 
@@ -100,8 +222,46 @@ func (r *RouteHandler) EnableAuth(router *gin.Engine, role string, sgnKey string
 	router.Use(auth.JWTAuth(auth.NewVaultTokenStore(role), sgnKey, nil))
 }
 
+// EnableAudit enables posting the audit records of the served recommendations to the webhook
+func (r *RouteHandler) EnableAudit(webhook *audit.Webhook) {
+	r.auditor = webhook
+}
+
+// EnableProviderHealth enables failing fast the requests to the providers the health source marked unhealthy
+func (r *RouteHandler) EnableProviderHealth(health *providerhealth.Source) {
+	r.health = health
+}
+
+// EnableETags enables answering the repeated cluster recommendation requests with 304 Not Modified
+// while the generation of the product details of the region doesn't change and their prices are not older than the max price age
+func (r *RouteHandler) EnableETags(generations GenerationSource, maxPriceAge time.Duration) {
+	r.generations = generations
+	r.maxPriceAge = maxPriceAge
+}
+
+// audit records the served recommendation if auditing is enabled
+func (r *RouteHandler) audit(params GetRecommendationParams, req interface{}, resp *recommender.ClusterRecommendationResp) {
+	if r.auditor == nil {
+		return
+	}
+	r.auditor.Audit(audit.Record{
+		Timestamp: time.Now().UTC(),
+		Provider:  params.Provider,
+		Service:   params.Service,
+		Region:    params.Region,
+		Request:   req,
+		NodePools: resp.NodePools,
+	})
+}
+
 func (r *RouteHandler) signalStatus(c *gin.Context) {
-	c.JSON(http.StatusOK, "ok")
+	c.JSON(http.StatusOK, StatusResponse{
+		Status:     "ok",
+		Version:    r.buildInfo.Version,
+		CommitHash: r.buildInfo.CommitHash,
+		BuildDate:  r.buildInfo.BuildDate,
+		Uptime:     time.Since(r.startTime).Round(time.Second).String(),
+	})
 }
 
 func (r *RouteHandler) EnableMetrics(router *gin.Engine, metricsAddr string) {
@@ -0,0 +1,157 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/banzaicloud/telescopes/internal/platform/buildinfo"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getCorsConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(config cors.Config)
+	}{
+		{
+			name: "all origins are allowed by default",
+			env:  map[string]string{},
+			check: func(config cors.Config) {
+				assert.True(t, config.AllowAllOrigins)
+				assert.Nil(t, config.AllowOrigins)
+				assert.Equal(t, []string{"Origin", "Authorization", "Content-Type"}, config.AllowHeaders)
+			},
+		},
+		{
+			name: "restricted origins, methods and headers",
+			env: map[string]string{
+				corsOrigins: "https://banzaicloud.com, https://beta.banzaicloud.io,",
+				corsMethods: "GET,POST",
+				corsHeaders: "Authorization",
+			},
+			check: func(config cors.Config) {
+				assert.False(t, config.AllowAllOrigins)
+				assert.Equal(t, []string{"https://banzaicloud.com", "https://beta.banzaicloud.io"}, config.AllowOrigins)
+				assert.Equal(t, []string{"GET", "POST"}, config.AllowMethods)
+				assert.Equal(t, []string{"Authorization"}, config.AllowHeaders)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				os.Setenv(key, value)
+			}
+			defer func() {
+				for key := range test.env {
+					os.Unsetenv(key)
+				}
+			}()
+
+			test.check(getCorsConfig())
+		})
+	}
+}
+
+func Test_getCorsConfigRestrictsOrigins(t *testing.T) {
+	os.Setenv(corsOrigins, "https://banzaicloud.com")
+	defer os.Unsetenv(corsOrigins)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(cors.New(getCorsConfig()))
+	router.GET("/status", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for origin, status := range map[string]int{
+		"https://banzaicloud.com": http.StatusOK,
+		"https://other-origin.io": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, status, w.Code, origin)
+	}
+}
+
+func TestRouteHandler_signalStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routeHandler := NewRouteHandler(nil, buildinfo.New("0.1.0", "0a1b2c3", "2019-05-01T10:00:00Z"), nil, nil, logur.NewTestLogger())
+	router.GET("/status", routeHandler.signalStatus)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var status StatusResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, "ok", status.Status)
+	assert.Equal(t, "0.1.0", status.Version)
+	assert.Equal(t, "0a1b2c3", status.CommitHash)
+	assert.NotEmpty(t, status.Uptime)
+}
+
+func TestRouteHandler_rateLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit string
+		check func(handlers []gin.HandlerFunc)
+	}{
+		{
+			name:  "no rate limit by default",
+			limit: "",
+			check: func(handlers []gin.HandlerFunc) {
+				assert.Empty(t, handlers)
+			},
+		},
+		{
+			name:  "invalid rate limit ignored",
+			limit: "ten",
+			check: func(handlers []gin.HandlerFunc) {
+				assert.Empty(t, handlers)
+			},
+		},
+		{
+			name:  "rate limit configured",
+			limit: "0.5",
+			check: func(handlers []gin.HandlerFunc) {
+				assert.Equal(t, 1, len(handlers))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Setenv(rateLimit, test.limit)
+			defer os.Unsetenv(rateLimit)
+
+			routeHandler := NewRouteHandler(nil, buildinfo.New("0.1.0", "0a1b2c3", "2019-05-01T10:00:00Z"), nil, nil, logur.NewTestLogger())
+			test.check(routeHandler.rateLimitMiddleware())
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+)
+
+// savingsRequests derives the on-demand only and the spot only variants of the cluster recommendation request
+func savingsRequests(req recommender.ClusterRecommendationReq) (recommender.ClusterRecommendationReq, recommender.ClusterRecommendationReq) {
+	onDemand, spot := req, req
+	onDemand.OnDemandOnly, onDemand.OnDemandPct = true, 100
+	spot.OnDemandOnly, spot.OnDemandPct = false, 0
+	return onDemand, spot
+}
+
+// estimateSavings compares the total prices of the on-demand only and the spot only recommendations
+// the spot recommendation leaves out the instance types without spot prices, its warnings are reported as notes
+func estimateSavings(onDemand, spot *recommender.ClusterRecommendationResp) SavingsResponse {
+	response := SavingsResponse{
+		Currency:      onDemand.Currency,
+		OnDemandPrice: onDemand.Accuracy.RecTotalPrice,
+		SpotPrice:     spot.Accuracy.RecTotalPrice,
+		Notes:         spot.Warnings,
+	}
+	response.Savings = response.OnDemandPrice - response.SpotPrice
+	if response.OnDemandPrice > 0 {
+		response.SavingsPct = response.Savings / response.OnDemandPrice * 100
+	}
+	return response
+}
@@ -0,0 +1,81 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_estimateSavings(t *testing.T) {
+	tests := []struct {
+		name     string
+		onDemand *recommender.ClusterRecommendationResp
+		spot     *recommender.ClusterRecommendationResp
+		check    func(savings SavingsResponse)
+	}{
+		{
+			name:     "spot savings",
+			onDemand: &recommender.ClusterRecommendationResp{Currency: "USD", Accuracy: recommender.ClusterRecommendationAccuracy{RecTotalPrice: 1.2}},
+			spot:     &recommender.ClusterRecommendationResp{Currency: "USD", Accuracy: recommender.ClusterRecommendationAccuracy{RecTotalPrice: 0.3}},
+			check: func(savings SavingsResponse) {
+				assert.Equal(t, "USD", savings.Currency)
+				assert.Equal(t, 1.2, savings.OnDemandPrice)
+				assert.Equal(t, 0.3, savings.SpotPrice)
+				assert.InDelta(t, 0.9, savings.Savings, 1e-9)
+				assert.InDelta(t, 75, savings.SavingsPct, 1e-9)
+				assert.Empty(t, savings.Notes)
+			},
+		},
+		{
+			name:     "no spot prices",
+			onDemand: &recommender.ClusterRecommendationResp{Accuracy: recommender.ClusterRecommendationAccuracy{RecTotalPrice: 1.2}},
+			spot: &recommender.ClusterRecommendationResp{
+				Accuracy: recommender.ClusterRecommendationAccuracy{RecTotalPrice: 1.2},
+				Warnings: []string{"no spot prices available, only on-demand nodes are recommended"},
+			},
+			check: func(savings SavingsResponse) {
+				assert.Zero(t, savings.Savings)
+				assert.Zero(t, savings.SavingsPct)
+				assert.Equal(t, []string{"no spot prices available, only on-demand nodes are recommended"}, savings.Notes)
+			},
+		},
+		{
+			name:     "no on-demand price",
+			onDemand: &recommender.ClusterRecommendationResp{},
+			spot:     &recommender.ClusterRecommendationResp{},
+			check: func(savings SavingsResponse) {
+				assert.Zero(t, savings.SavingsPct)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(estimateSavings(test.onDemand, test.spot))
+		})
+	}
+}
+
+func Test_savingsRequests(t *testing.T) {
+	onDemand, spot := savingsRequests(recommender.ClusterRecommendationReq{SumCpu: 8, OnDemandPct: 30})
+
+	assert.True(t, onDemand.OnDemandOnly)
+	assert.Equal(t, 100, onDemand.OnDemandPct)
+	assert.False(t, spot.OnDemandOnly)
+	assert.Equal(t, 0, spot.OnDemandPct)
+	assert.Equal(t, 8.0, spot.SumCpu)
+}
@@ -0,0 +1,140 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+)
+
+// schemaTypes are the request and response types described in the served OpenAPI spec
+var schemaTypes = map[string]interface{}{
+	"ClusterRecommendationReq":         recommender.ClusterRecommendationReq{},
+	"ClusterScaleoutRecommendationReq": recommender.ClusterScaleoutRecommendationReq{},
+	"MultiClusterRecommendationReq":    recommender.MultiClusterRecommendationReq{},
+	"BatchRecommendationItem":          BatchRecommendationItem{},
+	"RecommendationResponse":           RecommendationResponse{},
+	"BatchRecommendationResponse":      BatchRecommendationResponse{},
+	"ProductsResponse":                 ProductsResponse{},
+}
+
+// openApiSpec assembles an OpenAPI document with the schemas of the request and response types
+// the schemas are generated from the Go structs so they are always in sync with the API
+func openApiSpec(version string) map[string]interface{} {
+	schemas := make(map[string]interface{}, len(schemaTypes))
+	for name, t := range schemaTypes {
+		schemas[name] = jsonSchema(reflect.TypeOf(t))
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]interface{}{
+			"title":   "Telescopes",
+			"version": version,
+		},
+		"paths": map[string]interface{}{},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}
+}
+
+// jsonSchema builds the JSON schema of the given type based on its json and binding struct tags
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonSchema(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		var required []string
+		addProperties(t, properties, &required)
+
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem())}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// addProperties collects the schema of the exported fields of the struct, embedded structs are flattened
+func addProperties(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addProperties(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := jsonSchema(field.Type)
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			addRule(schema, rule, required, name)
+		}
+		properties[name] = schema
+	}
+}
+
+// addRule translates a binding rule of a field to the field's schema
+func addRule(schema map[string]interface{}, rule string, required *[]string, name string) {
+	numeric := schema["type"] == "number" || schema["type"] == "integer"
+	switch {
+	case rule == "required":
+		*required = append(*required, name)
+	case numeric && strings.HasPrefix(rule, "min="):
+		if min, err := strconv.ParseFloat(strings.TrimPrefix(rule, "min="), 64); err == nil {
+			schema["minimum"] = min
+		}
+	case numeric && strings.HasPrefix(rule, "max="):
+		if max, err := strconv.ParseFloat(strings.TrimPrefix(rule, "max="), 64); err == nil {
+			schema["maximum"] = max
+		}
+	case strings.HasPrefix(rule, "eq="):
+		var enum []string
+		for _, value := range strings.Split(rule, "|") {
+			enum = append(enum, strings.TrimPrefix(value, "eq="))
+		}
+		schema["enum"] = enum
+	}
+}
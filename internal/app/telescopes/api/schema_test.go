@@ -0,0 +1,39 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_openApiSpec(t *testing.T) {
+	spec := openApiSpec("0.0.1")
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	req := schemas["ClusterRecommendationReq"].(map[string]interface{})
+	properties := req["properties"].(map[string]interface{})
+
+	assert.Equal(t, map[string]interface{}{"type": "number", "minimum": float64(1)}, properties["sumCpu"])
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}, properties["zones"])
+	assert.Equal(t, []string{"cost", "stability", "balanced", "carbon"}, properties["objective"].(map[string]interface{})["enum"])
+
+	resp := schemas["RecommendationResponse"].(map[string]interface{})
+	assert.Contains(t, resp["properties"], "nodePools", "embedded fields should be flattened")
+
+	scaleOut := schemas["ClusterScaleoutRecommendationReq"].(map[string]interface{})
+	assert.Equal(t, []string{"actualLayout"}, scaleOut["required"])
+}
@@ -0,0 +1,64 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"sort"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+)
+
+const (
+	// keys the products can be sorted by
+	sortByPrice    = "price"
+	sortByCpu      = "cpu"
+	sortByMem      = "mem"
+	sortByDiscount = "discount"
+
+	// descending sort order, the products are sorted in ascending order by default
+	orderDesc = "desc"
+)
+
+// sortProducts sorts the products in place by the given key (on-demand price by default) in ascending or descending order
+// the sort is stable, products with equal keys keep their original order
+func sortProducts(products []recommender.VirtualMachine, key, order string) {
+	value := func(p recommender.VirtualMachine) float64 {
+		switch key {
+		case sortByCpu:
+			return p.Cpus
+		case sortByMem:
+			return p.Mem
+		case sortByDiscount:
+			return spotDiscount(p)
+		default:
+			return p.OnDemandPrice
+		}
+	}
+
+	sort.SliceStable(products, func(i, j int) bool {
+		if order == orderDesc {
+			return value(products[i]) > value(products[j])
+		}
+		return value(products[i]) < value(products[j])
+	})
+}
+
+// spotDiscount calculates the discount of the spot price compared to the on-demand price in percent, 0 if either price is unknown
+func spotDiscount(p recommender.VirtualMachine) float64 {
+	if p.OnDemandPrice == 0 || p.AvgPrice == 0 {
+		return 0
+	}
+	return (p.OnDemandPrice - p.AvgPrice) / p.OnDemandPrice * 100
+}
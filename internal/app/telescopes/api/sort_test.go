@@ -0,0 +1,116 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sortProducts(t *testing.T) {
+	products := []recommender.VirtualMachine{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.2, AvgPrice: 0.08},
+		{Type: "c5.2xlarge", Cpus: 8, Mem: 16, OnDemandPrice: 0.34, AvgPrice: 0.3},
+		{Type: "r5.large", Cpus: 2, Mem: 16, OnDemandPrice: 0.126, AvgPrice: 0.1},
+		{Type: "t3.small", Cpus: 2, Mem: 2, OnDemandPrice: 0.02},
+	}
+	tests := []struct {
+		name  string
+		key   string
+		order string
+		check func(types []string)
+	}{
+		{
+			name: "price ascending by default",
+			check: func(types []string) {
+				assert.Equal(t, []string{"t3.small", "r5.large", "m5.xlarge", "c5.2xlarge"}, types)
+			},
+		},
+		{
+			name:  "price descending",
+			key:   sortByPrice,
+			order: orderDesc,
+			check: func(types []string) {
+				assert.Equal(t, []string{"c5.2xlarge", "m5.xlarge", "r5.large", "t3.small"}, types)
+			},
+		},
+		{
+			name:  "cpu ascending, equal keys keep their order",
+			key:   sortByCpu,
+			order: "asc",
+			check: func(types []string) {
+				assert.Equal(t, []string{"r5.large", "t3.small", "m5.xlarge", "c5.2xlarge"}, types)
+			},
+		},
+		{
+			name:  "cpu descending",
+			key:   sortByCpu,
+			order: orderDesc,
+			check: func(types []string) {
+				assert.Equal(t, []string{"c5.2xlarge", "m5.xlarge", "r5.large", "t3.small"}, types)
+			},
+		},
+		{
+			name: "mem ascending",
+			key:  sortByMem,
+			check: func(types []string) {
+				assert.Equal(t, []string{"t3.small", "m5.xlarge", "c5.2xlarge", "r5.large"}, types)
+			},
+		},
+		{
+			name:  "mem descending",
+			key:   sortByMem,
+			order: orderDesc,
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.xlarge", "c5.2xlarge", "r5.large", "t3.small"}, types)
+			},
+		},
+		{
+			name: "discount ascending, unknown spot price means no discount",
+			key:  sortByDiscount,
+			check: func(types []string) {
+				assert.Equal(t, []string{"t3.small", "c5.2xlarge", "r5.large", "m5.xlarge"}, types)
+			},
+		},
+		{
+			name:  "discount descending",
+			key:   sortByDiscount,
+			order: orderDesc,
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.xlarge", "r5.large", "c5.2xlarge", "t3.small"}, types)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sorted := make([]recommender.VirtualMachine, len(products))
+			copy(sorted, products)
+
+			sortProducts(sorted, test.key, test.order)
+			test.check(vmTypes(sorted))
+		})
+	}
+}
+
+// vmTypes collects the instance types of the products
+func vmTypes(products []recommender.VirtualMachine) []string {
+	types := make([]string, 0, len(products))
+	for _, p := range products {
+		types = append(types, p.Type)
+	}
+	return types
+}
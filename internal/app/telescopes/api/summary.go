@@ -0,0 +1,52 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+)
+
+// recommendationSummary renders a short human-readable summary of the recommended node pools,
+// eg. "3× m5.xlarge (12 vCPU, 48 GB) ≈ $0.18/hr spot"; empty node pools are left out
+func recommendationSummary(resp recommender.ClusterRecommendationResp) string {
+	var pools []string
+	for _, np := range resp.NodePools {
+		if np.SumNodes == 0 {
+			continue
+		}
+		pools = append(pools, fmt.Sprintf("%d× %s (%s vCPU, %s GB) ≈ %s/hr %s",
+			np.SumNodes,
+			np.VmType.Type,
+			formatFloat(float64(np.SumNodes)*np.VmType.Cpus),
+			formatFloat(float64(np.SumNodes)*np.VmType.Mem),
+			formatPrice(np.PoolPrice(), resp.Currency),
+			np.VmClass))
+	}
+	return strings.Join(pools, ", ")
+}
+
+// formatPrice renders a price rounded to four decimals, prefixed with the dollar sign for USD and
+// followed by the currency code otherwise
+func formatPrice(price float64, currency string) string {
+	rounded := formatFloat(math.Round(price*10000) / 10000)
+	if currency == "" || currency == recommender.DefaultCurrency {
+		return "$" + rounded
+	}
+	return rounded + " " + currency
+}
@@ -0,0 +1,70 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_recommendationSummary(t *testing.T) {
+	nodePools := []recommender.NodePool{
+		{
+			VmType:   recommender.VirtualMachine{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.06},
+			SumNodes: 3,
+			VmClass:  recommender.Spot,
+		},
+		{
+			VmType:   recommender.VirtualMachine{Type: "c5.large", Cpus: 2, Mem: 4, OnDemandPrice: 0.097, AvgPrice: 0.035},
+			SumNodes: 2,
+			VmClass:  recommender.Regular,
+		},
+		{
+			VmType:   recommender.VirtualMachine{Type: "r5.large", Cpus: 2, Mem: 16, OnDemandPrice: 0.126, AvgPrice: 0.04},
+			SumNodes: 0,
+			VmClass:  recommender.Spot,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		currency string
+		check    func(summary string)
+	}{
+		{
+			name:     "the summary matches the node counts and pool prices",
+			currency: recommender.DefaultCurrency,
+			check: func(summary string) {
+				// 3 x 0.06 spot and 2 x 0.097 regular
+				assert.Equal(t, "3× m5.xlarge (12 vCPU, 48 GB) ≈ $0.18/hr spot, 2× c5.large (4 vCPU, 8 GB) ≈ $0.194/hr regular", summary)
+				assert.NotContains(t, summary, "r5.large", "empty node pools should be left out")
+			},
+		},
+		{
+			name:     "other currencies are rendered by their code",
+			currency: "EUR",
+			check: func(summary string) {
+				assert.Equal(t, "3× m5.xlarge (12 vCPU, 48 GB) ≈ 0.18 EUR/hr spot, 2× c5.large (4 vCPU, 8 GB) ≈ 0.194 EUR/hr regular", summary)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(recommendationSummary(recommender.ClusterRecommendationResp{NodePools: nodePools, Currency: test.currency}))
+		})
+	}
+}
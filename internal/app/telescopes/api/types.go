@@ -14,10 +14,15 @@
 
 package api
 
-import "github.com/banzaicloud/telescopes/pkg/recommender"
+import (
+	"strings"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/banzaicloud/telescopes/pkg/recommender/providerhealth"
+)
 
 // GetRecommendationParams is a placeholder for the recommendation route's path parameters
-// swagger:parameters recommendCluster recommendClusterScaleOut
+// swagger:parameters recommendCluster recommendClusterScaleOut getProducts getCheapestProduct validateClusterRecommendation
 type GetRecommendationParams struct {
 	// in:path
 	Provider string `binding:"required,provider" json:"provider"`
@@ -29,7 +34,240 @@ type GetRecommendationParams struct {
 	Region string `binding:"required,region" json:"region"`
 }
 
+// normalize brings the path parameters to the form used by the cloud info service
+func (p *GetRecommendationParams) normalize() {
+	p.Region = strings.ToLower(p.Region)
+}
+
+// RecommendationQueryParams is a placeholder for the recommendation routes' query parameters
+// swagger:parameters recommendCluster recommendClusterScaleOut
+type RecommendationQueryParams struct {
+	// Format of the response: json (default), csv, eksctl (a managedNodeGroups fragment of an eksctl cluster config, amazon only)
+	// or capi (Cluster API MachineDeployment and AWSMachineTemplate manifests, amazon only)
+	// in:query
+	Format string `form:"format" json:"format" binding:"omitempty,eq=json|eq=csv|eq=eksctl|eq=capi"`
+
+	// Comma separated list of the virtual machine fields returned in the json response, eg. type,avgPrice (all fields by default)
+	// in:query
+	Fields string `form:"fields" json:"fields"`
+
+	// If true, the json response carries a short human-readable summary of the recommended node pools
+	// in:query
+	Summary bool `form:"summary" json:"summary"`
+}
+
+// BatchRecommendationItem encapsulates a cluster recommendation request of a batch
+type BatchRecommendationItem struct {
+	Provider string                               `json:"provider"`
+	Service  string                               `json:"service"`
+	Region   string                               `json:"region"`
+	Request  recommender.ClusterRecommendationReq `json:"request"`
+}
+
+// BatchRecommendationResult holds the outcome of a batch item, either the recommendation or the error
+type BatchRecommendationResult struct {
+	Provider string                                 `json:"provider"`
+	Service  string                                 `json:"service"`
+	Region   string                                 `json:"region"`
+	Response *recommender.ClusterRecommendationResp `json:"response,omitempty"`
+	Error    string                                 `json:"error,omitempty"`
+}
+
+// BatchRecommendationResponse encapsulates the results of a batch recommendation, in the order of the request items
+// swagger:model BatchRecommendationResponse
+type BatchRecommendationResponse struct {
+	Results []BatchRecommendationResult `json:"results"`
+}
+
+// CheapestRegionRequest encapsulates a cluster recommendation request to be performed in each of the candidate regions
+// swagger:parameters recommendCheapestRegion
+type CheapestRegionRequest struct {
+	// Candidate regions of the provider's service
+	Regions []string `json:"regions" binding:"required,min=1,max=100"`
+	// The cluster recommendation request
+	Request recommender.ClusterRecommendationReq `json:"request" binding:"required"`
+}
+
+// CheapestRegionResponse holds the recommendation of the cheapest region along with the outcome of every candidate region
+// swagger:model CheapestRegionResponse
+type CheapestRegionResponse struct {
+	// The region with the lowest total price
+	Region string `json:"region"`
+	// The recommendation in the cheapest region
+	Response *recommender.ClusterRecommendationResp `json:"response"`
+	// The recommendations or the errors per candidate region, in the order of the request
+	Regions []BatchRecommendationResult `json:"regions"`
+}
+
+// SavingsResponse holds the estimated savings of a spot only cluster compared to an on-demand only one
+// swagger:model SavingsResponse
+type SavingsResponse struct {
+	// Currency of the prices
+	Currency string `json:"currency"`
+	// Hourly total price of the on-demand only recommendation
+	OnDemandPrice float64 `json:"onDemandPrice"`
+	// Hourly total price of the spot only recommendation
+	SpotPrice float64 `json:"spotPrice"`
+	// Hourly savings of the spot only recommendation
+	Savings float64 `json:"savings"`
+	// Savings in percent of the on-demand price
+	SavingsPct float64 `json:"savingsPct"`
+	// Degradations of the spot recommendation, eg. no spot prices available
+	Notes []string `json:"notes,omitempty"`
+}
+
+// DiffRequest encapsulates a cluster recommendation request and a recommendation previously returned for it
+// swagger:parameters diffRecommendation
+type DiffRequest struct {
+	// The cluster recommendation request
+	Request recommender.ClusterRecommendationReq `json:"request" binding:"required"`
+	// The recommendation the current one is compared to
+	Previous recommender.ClusterRecommendationResp `json:"previous" binding:"required"`
+}
+
+// NodePoolDiff describes a node pool added, removed or changed since the previous recommendation
+type NodePoolDiff struct {
+	// Instance type of the node pool
+	Type string `json:"type"`
+	// Regular or spot node pool
+	VmClass string `json:"vmClass"`
+	// Role in the cluster, eg. master or worker
+	Role string `json:"role"`
+	// Number of nodes in the previous recommendation, 0 if the node pool was added
+	PreviousNodes int `json:"previousNodes"`
+	// Number of nodes in the current recommendation, 0 if the node pool was removed
+	Nodes int `json:"nodes"`
+	// Hourly price of a node in the previous recommendation, 0 if the node pool was added
+	PreviousPrice float64 `json:"previousPrice"`
+	// Hourly price of a node in the current recommendation, 0 if the node pool was removed
+	Price float64 `json:"price"`
+}
+
+// DiffResponse holds the changes of the current recommendation compared to the previous one
+// swagger:model DiffResponse
+type DiffResponse struct {
+	// Currency of the prices
+	Currency string `json:"currency"`
+	// Node pools in the current recommendation only
+	Added []NodePoolDiff `json:"added"`
+	// Node pools in the previous recommendation only
+	Removed []NodePoolDiff `json:"removed"`
+	// Node pools in both recommendations whose node count or node price changed
+	Changed []NodePoolDiff `json:"changed"`
+	// Hourly total price of the previous recommendation
+	PreviousTotalPrice float64 `json:"previousTotalPrice"`
+	// Hourly total price of the current recommendation
+	TotalPrice float64 `json:"totalPrice"`
+	// The current recommendation
+	Current *recommender.ClusterRecommendationResp `json:"current"`
+}
+
+// StatusResponse holds the status of the application along with its build information
+type StatusResponse struct {
+	Status     string `json:"status"`
+	Version    string `json:"version"`
+	CommitHash string `json:"commit_hash"`
+	BuildDate  string `json:"build_date"`
+	Uptime     string `json:"uptime"`
+}
+
+// ValidationResponse holds the outcome of a recommendation request validation
+// swagger:model ValidationResponse
+type ValidationResponse struct {
+	// Valid signals whether the request can be used for a recommendation
+	Valid bool `json:"valid"`
+	// Errors lists the violated rules of an invalid request
+	Errors []string `json:"errors,omitempty"`
+}
+
 // RecommendationResponse encapsulates the recommendation response
 type RecommendationResponse struct {
 	recommender.ClusterRecommendationResp
 }
+
+// GetProductsQueryParams is a placeholder for the products route's query parameters
+// swagger:parameters getProducts
+type GetProductsQueryParams struct {
+	// Minimum number of CPUs of the listed instance types
+	// in:query
+	MinCpu float64 `form:"minCpu" json:"minCpu" binding:"min=0"`
+
+	// Minimum memory (GB) of the listed instance types
+	// in:query
+	MinMem float64 `form:"minMem" json:"minMem" binding:"min=0"`
+
+	// Maximum number of instance types returned, defaults to 100
+	// in:query
+	Limit int `form:"limit" json:"limit" binding:"min=0,max=500"`
+
+	// Number of instance types skipped from the beginning of the list
+	// in:query
+	Offset int `form:"offset" json:"offset" binding:"min=0"`
+
+	// Key the instance types are sorted by: price (on-demand price, default), cpu, mem or discount (spot discount)
+	// in:query
+	Sort string `form:"sort" json:"sort" binding:"omitempty,eq=price|eq=cpu|eq=mem|eq=discount"`
+
+	// Sort order: asc (default) or desc
+	// in:query
+	Order string `form:"order" json:"order" binding:"omitempty,eq=asc|eq=desc"`
+}
+
+// GetCheapestProductQueryParams is a placeholder for the cheapest product route's query parameters
+// swagger:parameters getCheapestProduct
+type GetCheapestProductQueryParams struct {
+	// Minimum number of CPUs of the instance type
+	// in:query
+	Cpu float64 `form:"cpu" json:"cpu" binding:"min=0"`
+
+	// Minimum memory (GB) of the instance type
+	// in:query
+	Mem float64 `form:"mem" json:"mem" binding:"min=0"`
+
+	// Price the instance types are compared by: regular (on-demand price, default) or spot (average spot price)
+	// in:query
+	VmClass string `form:"vmClass" json:"vmClass" binding:"omitempty,eq=regular|eq=spot"`
+}
+
+// CompareProductsQueryParams is a placeholder for the product comparison route's query parameters
+// swagger:parameters compareProducts
+type CompareProductsQueryParams struct {
+	// The instance types to compare
+	// in:query
+	A string `form:"a" json:"a" binding:"required"`
+	// in:query
+	B string `form:"b" json:"b" binding:"required"`
+}
+
+// ProductComparison holds the attributes and prices of an instance type compared to another one
+type ProductComparison struct {
+	Type          string  `json:"type"`
+	Cpus          float64 `json:"cpusPerVm"`
+	Mem           float64 `json:"memPerVm"`
+	Gpus          float64 `json:"gpusPerVm"`
+	OnDemandPrice float64 `json:"onDemandPrice"`
+	SpotPrice     float64 `json:"spotPrice"`
+	// Discount of the spot price compared to the on-demand price in percent, 0 if either price is unknown
+	SpotDiscount float64 `json:"spotDiscount"`
+}
+
+// CompareProductsResponse encapsulates the comparison of two instance types
+// swagger:model CompareProductsResponse
+type CompareProductsResponse struct {
+	A ProductComparison `json:"a"`
+	B ProductComparison `json:"b"`
+}
+
+// InfoResponse encapsulates the capabilities of the known providers and the health of the requested ones
+// swagger:model InfoResponse
+type InfoResponse struct {
+	Providers []recommender.ProviderCapabilities `json:"providers"`
+	// Health of the providers requested so far, only present if the provider health is tracked
+	Health []providerhealth.Status `json:"health,omitempty"`
+}
+
+// ProductsResponse encapsulates the instance types available in a region
+// swagger:model ProductsResponse
+type ProductsResponse struct {
+	Products []recommender.VirtualMachine `json:"products"`
+}
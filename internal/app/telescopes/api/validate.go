@@ -16,10 +16,14 @@ package api
 
 import (
 	"reflect"
+	"sort"
+	"strings"
 
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
 	"github.com/banzaicloud/telescopes/internal/platform/classifier"
 	"github.com/banzaicloud/telescopes/pkg/recommender"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/go-openapi/runtime"
 	"github.com/goph/emperror"
 	"github.com/pkg/errors"
 	"gopkg.in/go-playground/validator.v8"
@@ -104,8 +108,16 @@ type CloudInfoValidator interface {
 	Validate(params interface{}) error
 }
 
+// pathParamSource declares the cloud info operations required to validate the path parameters
+type pathParamSource interface {
+	GetProvider(prv string) (string, error)
+	GetService(prv string, svc string) (string, error)
+	GetRegion(prv, svc, reg string) (string, error)
+	GetRegions(provider, service string) ([]*models.Continent, error)
+}
+
 type pathParamValidator struct {
-	ciCli *recommender.CloudInfoClient
+	ciCli pathParamSource
 }
 
 // Validate validates path parameters against the connected cloud info service
@@ -154,12 +166,38 @@ func (ppV *pathParamValidator) validateService(prv, svc string) error {
 }
 
 func (ppV *pathParamValidator) validateRegion(prv, svc, region string) error {
-	if ciReg, e := ppV.ciCli.GetRegion(prv, svc, region); e != nil {
+	ciReg, e := ppV.ciCli.GetRegion(prv, svc, region)
+	if e != nil {
+		if _, ok := errors.Cause(e).(*runtime.APIError); !ok {
+			// the cloud info service couldn't be reached, the region can't be checked
+			return e
+		}
+	} else if ciReg != "" {
+		return nil
+	}
+
+	regions, e := ppV.validRegions(prv, svc)
+	if e != nil {
 		return e
-	} else if ciReg == "" {
-		return errors.New("region not found")
 	}
-	return nil
+	return errors.Errorf("region not found: %s, valid regions: %s", region, strings.Join(regions, ", "))
+}
+
+// validRegions lists the region identifiers available for the provider's service
+func (ppV *pathParamValidator) validRegions(prv, svc string) ([]string, error) {
+	continents, e := ppV.ciCli.GetRegions(prv, svc)
+	if e != nil {
+		return nil, e
+	}
+
+	var regions []string
+	for _, continent := range continents {
+		for _, region := range continent.Regions {
+			regions = append(regions, region.ID)
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
 }
 
 func NewCloudInfoValidator(ciCli *recommender.CloudInfoClient) CloudInfoValidator {
@@ -0,0 +1,89 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/go-openapi/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+type dummyPathParamSource struct {
+	regions []string
+}
+
+func (d *dummyPathParamSource) GetProvider(prv string) (string, error) {
+	return prv, nil
+}
+
+func (d *dummyPathParamSource) GetService(prv string, svc string) (string, error) {
+	return svc, nil
+}
+
+func (d *dummyPathParamSource) GetRegion(prv, svc, reg string) (string, error) {
+	for _, r := range d.regions {
+		if r == reg {
+			return reg, nil
+		}
+	}
+	return "", &runtime.APIError{Code: 404}
+}
+
+func (d *dummyPathParamSource) GetRegions(provider, service string) ([]*models.Continent, error) {
+	continent := &models.Continent{Name: "Europe"}
+	for _, r := range d.regions {
+		continent.Regions = append(continent.Regions, &models.Region{ID: r})
+	}
+	return []*models.Continent{continent}, nil
+}
+
+func TestPathParamValidator_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		pathParams GetRecommendationParams
+		check      func(err error)
+	}{
+		{
+			name:       "valid region",
+			pathParams: GetRecommendationParams{Provider: "amazon", Service: "compute", Region: "eu-west-1"},
+			check: func(err error) {
+				assert.Nil(t, err, "the error should be nil")
+			},
+		},
+		{
+			name:       "invalid region",
+			pathParams: GetRecommendationParams{Provider: "amazon", Service: "compute", Region: "eu-west-9"},
+			check: func(err error) {
+				assert.EqualError(t, err, "region not found: eu-west-9, valid regions: eu-central-1, eu-west-1")
+			},
+		},
+		{
+			name:       "region with different case",
+			pathParams: GetRecommendationParams{Provider: "amazon", Service: "compute", Region: "EU-West-1"},
+			check: func(err error) {
+				assert.Nil(t, err, "the error should be nil")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator := &pathParamValidator{&dummyPathParamSource{regions: []string{"eu-west-1", "eu-central-1"}}}
+			test.pathParams.normalize()
+			test.check(validator.Validate(test.pathParams))
+		})
+	}
+}
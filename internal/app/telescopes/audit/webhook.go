@@ -0,0 +1,142 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+	"github.com/pkg/errors"
+)
+
+const (
+	// queueSize is the number of records waiting to be sent, records beyond it are dropped
+	queueSize = 100
+	// maxAttempts is the number of times sending a record is attempted
+	maxAttempts = 3
+	// retryDelay is the delay before the first retry, it is doubled for every further retry
+	retryDelay = time.Second
+	// sendTimeout is the timeout of posting a single record
+	sendTimeout = 10 * time.Second
+)
+
+// Record is the audit record of a served recommendation
+type Record struct {
+	// Time the recommendation was served at
+	Timestamp time.Time `json:"timestamp"`
+	// The cloud provider
+	Provider string `json:"provider"`
+	// Provider's service
+	Service string `json:"service"`
+	// Service's region
+	Region string `json:"region"`
+	// The recommendation request
+	Request interface{} `json:"request"`
+	// The recommended node pools
+	NodePools []recommender.NodePool `json:"nodePools"`
+}
+
+// Webhook posts the audit records to a configured url in the background
+// records are queued and sent in order; sending never blocks the caller
+type Webhook struct {
+	log        logur.Logger
+	url        string
+	client     *http.Client
+	retryDelay time.Duration
+	queue      chan Record
+	done       sync.WaitGroup
+}
+
+// NewWebhook creates a webhook posting the audit records to the given url and starts sending the queued records
+func NewWebhook(log logur.Logger, url string) *Webhook {
+	w := &Webhook{
+		log:        log,
+		url:        url,
+		client:     &http.Client{Timeout: sendTimeout},
+		retryDelay: retryDelay,
+		queue:      make(chan Record, queueSize),
+	}
+
+	w.done.Add(1)
+	go w.run()
+
+	return w
+}
+
+// Audit queues the record for sending, the record is dropped if the queue is full
+func (w *Webhook) Audit(record Record) {
+	select {
+	case w.queue <- record:
+	default:
+		w.log.Warn("audit queue is full, dropping record",
+			map[string]interface{}{"provider": record.Provider, "service": record.Service, "region": record.Region})
+	}
+}
+
+// Close stops accepting records and waits for the queued ones to be sent
+func (w *Webhook) Close() {
+	close(w.queue)
+	w.done.Wait()
+}
+
+func (w *Webhook) run() {
+	defer w.done.Done()
+
+	for record := range w.queue {
+		if err := w.send(record); err != nil {
+			w.log.Error(err.Error(), map[string]interface{}{"provider": record.Provider, "service": record.Service, "region": record.Region})
+		}
+	}
+}
+
+// send posts the record to the webhook url, retrying with an increasing delay on failure
+func (w *Webhook) send(record Record) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return emperror.Wrap(err, "failed to marshal audit record")
+	}
+
+	delay := w.retryDelay
+	for attempt := 1; ; attempt++ {
+		err = w.post(body)
+		if err == nil || attempt == maxAttempts {
+			break
+		}
+		w.log.Debug("failed to send audit record, retrying", map[string]interface{}{"attempt": attempt, "error": err.Error()})
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	return emperror.Wrap(err, "failed to send audit record")
+}
+
+func (w *Webhook) post(body []byte) error {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubReceiver collects the payloads posted to it, failing the first given number of requests
+func stubReceiver(failures int) (*httptest.Server, chan map[string]interface{}) {
+	payloads := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		payloads <- payload
+	}))
+	return server, payloads
+}
+
+func TestWebhook_Audit(t *testing.T) {
+	record := Record{
+		Timestamp: time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC),
+		Provider:  "amazon",
+		Service:   "compute",
+		Region:    "eu-west-1",
+		Request:   recommender.ClusterRecommendationReq{SumCpu: 8, SumMem: 16, MinNodes: 2, MaxNodes: 4},
+		NodePools: []recommender.NodePool{
+			{VmType: recommender.VirtualMachine{Type: "m5.xlarge", Cpus: 4, Mem: 16}, SumNodes: 2, VmClass: recommender.Spot},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		check    func(payload map[string]interface{})
+	}{
+		{
+			name: "the record is posted as json",
+			check: func(payload map[string]interface{}) {
+				assert.Equal(t, "2019-05-01T12:00:00Z", payload["timestamp"])
+				assert.Equal(t, "amazon", payload["provider"])
+				assert.Equal(t, "compute", payload["service"])
+				assert.Equal(t, "eu-west-1", payload["region"])
+				assert.Equal(t, float64(8), payload["request"].(map[string]interface{})["sumCpu"])
+				nodePools := payload["nodePools"].([]interface{})
+				assert.Len(t, nodePools, 1)
+				assert.Equal(t, "m5.xlarge", nodePools[0].(map[string]interface{})["vm"].(map[string]interface{})["type"])
+				assert.Equal(t, float64(2), nodePools[0].(map[string]interface{})["sumNodes"])
+			},
+		},
+		{
+			name:     "failed posts are retried",
+			failures: maxAttempts - 1,
+			check: func(payload map[string]interface{}) {
+				assert.Equal(t, "amazon", payload["provider"])
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, payloads := stubReceiver(test.failures)
+			defer server.Close()
+
+			webhook := NewWebhook(logur.NewTestLogger(), server.URL)
+			webhook.retryDelay = time.Millisecond
+			webhook.Audit(record)
+			webhook.Close()
+
+			select {
+			case payload := <-payloads:
+				test.check(payload)
+			default:
+				t.Fatal("no record received")
+			}
+		})
+	}
+}
@@ -27,7 +27,11 @@ import (
 const (
 	cloudInfoCliErrTag  = "cloud-info-client"
 	recommenderErrorTag = "recommender"
+	unavailableErrTag   = "unavailable"
+	unprocessableErrTag = "unprocessable"
 	ValidationErrTag    = "validation"
+	NotFoundErrTag      = "not-found"
+	TimeoutErrTag       = "timeout"
 )
 
 // Classifier represents a contract to classify passed in structs
@@ -132,6 +136,22 @@ func (erc *errClassifier) classifyGenericError(e error, ctx []interface{}) *prob
 		problem = problems.NewValidationProblem(http.StatusBadRequest, e.Error())
 	}
 
+	if hasLabel(ctx, NotFoundErrTag) {
+		problem = problems.NewDetailedProblem(http.StatusNotFound, e.Error())
+	}
+
+	if hasLabel(ctx, TimeoutErrTag) {
+		problem = problems.NewDetailedProblem(http.StatusGatewayTimeout, e.Error())
+	}
+
+	if hasLabel(ctx, unavailableErrTag) {
+		problem = problems.NewDetailedProblem(http.StatusServiceUnavailable, e.Error())
+	}
+
+	if hasLabel(ctx, unprocessableErrTag) {
+		problem = problems.NewDetailedProblem(http.StatusUnprocessableEntity, e.Error())
+	}
+
 	return problem
 }
 
@@ -64,6 +64,38 @@ func TestErrResponseClassifier_Classify(t *testing.T) {
 				assert.Equal(t, http.StatusBadRequest, pb.Status, "invalid http status code")
 			},
 		},
+		{
+			name:  "generic error -  not found",
+			error: emperror.With(errors.New("test not found error"), NotFoundErrTag),
+			checker: func(t *testing.T, pb *problems.ProblemWrapper, e error) {
+				assert.Nil(t, e, "could not create classifier")
+				assert.Equal(t, http.StatusNotFound, pb.Status, "invalid http status code")
+			},
+		},
+		{
+			name:  "generic error -  provider unavailable",
+			error: emperror.With(errors.New("test unavailable error"), unavailableErrTag),
+			checker: func(t *testing.T, pb *problems.ProblemWrapper, e error) {
+				assert.Nil(t, e, "could not create classifier")
+				assert.Equal(t, http.StatusServiceUnavailable, pb.Status, "invalid http status code")
+			},
+		},
+		{
+			name:  "generic error -  unprocessable in the region",
+			error: emperror.With(errors.New("test no zones error"), unprocessableErrTag),
+			checker: func(t *testing.T, pb *problems.ProblemWrapper, e error) {
+				assert.Nil(t, e, "could not create classifier")
+				assert.Equal(t, http.StatusUnprocessableEntity, pb.Status, "invalid http status code")
+			},
+		},
+		{
+			name:  "generic error -  timeout",
+			error: emperror.With(errors.New("test timeout error"), TimeoutErrTag),
+			checker: func(t *testing.T, pb *problems.ProblemWrapper, e error) {
+				assert.Nil(t, e, "could not create classifier")
+				assert.Equal(t, http.StatusGatewayTimeout, pb.Status, "invalid http status code")
+			},
+		},
 		{
 			name:  "generic error -  no tags",
 			error: emperror.With(errors.New("test error - no context")),
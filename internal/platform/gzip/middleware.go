@@ -0,0 +1,83 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bufferedWriter holds back the response body until the handlers complete, so that its size is known
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write implements the io.Writer interface.
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+// WriteString implements the io.StringWriter interface.
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// Middleware returns a gin compatible handler compressing the responses of at least minLength bytes
+// for the clients accepting gzip encoding.
+func Middleware(minLength int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.Request) {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Accept-Encoding")
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.body.Len() < minLength || c.Writer.Header().Get("Content-Encoding") != "" {
+			// an empty body is not written, so that gin can still respond eg. to unknown routes
+			if writer.body.Len() > 0 {
+				_, _ = c.Writer.Write(writer.body.Bytes())
+			}
+			return
+		}
+
+		c.Header("Content-Encoding", "gzip")
+		c.Writer.Header().Del("Content-Length")
+
+		gz := gzip.NewWriter(c.Writer)
+		_, _ = gz.Write(writer.body.Bytes())
+		_ = gz.Close()
+	}
+}
+
+// acceptsGzip checks whether the client accepts gzip encoded responses
+func acceptsGzip(req *http.Request) bool {
+	for _, encoding := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.Split(encoding, ";")[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
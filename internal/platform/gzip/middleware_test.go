@@ -0,0 +1,103 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gzip
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	largeBody := strings.Repeat("recommendation ", 100)
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		check          func(resp *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "large response compressed",
+			path:           "/large",
+			acceptEncoding: "gzip, deflate",
+			check: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, "gzip", resp.Header().Get("Content-Encoding"))
+
+				reader, err := gzip.NewReader(resp.Body)
+				assert.Nil(t, err, "the body should be gzip encoded")
+				body, err := ioutil.ReadAll(reader)
+				assert.Nil(t, err, "the body should be readable")
+				assert.Equal(t, largeBody, string(body))
+			},
+		},
+		{
+			name:           "small response not compressed",
+			path:           "/status",
+			acceptEncoding: "gzip",
+			check: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, "", resp.Header().Get("Content-Encoding"))
+				assert.Equal(t, "ok", resp.Body.String())
+			},
+		},
+		{
+			name:           "gzip not accepted",
+			path:           "/large",
+			acceptEncoding: "",
+			check: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, "", resp.Header().Get("Content-Encoding"))
+				assert.Equal(t, largeBody, resp.Body.String())
+			},
+		},
+		{
+			name:           "unknown route",
+			path:           "/unknown",
+			acceptEncoding: "gzip",
+			check: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+				assert.Equal(t, "", resp.Header().Get("Content-Encoding"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(Middleware(1024))
+			router.GET("/large", func(c *gin.Context) {
+				c.String(http.StatusOK, largeBody)
+			})
+			router.GET("/status", func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			test.check(resp)
+		})
+	}
+}
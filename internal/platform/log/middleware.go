@@ -60,11 +60,14 @@ type middleware struct {
 }
 
 func (m *middleware) Handle(ctx *gin.Context) {
-	if header := ctx.GetHeader(m.header); header != "" {
-		ctx.Set(ContextKey, header)
-	} else {
-		ctx.Set(ContextKey, uuid.Must(uuid.NewV4()).String())
+	cid := ctx.GetHeader(m.header)
+	if cid == "" {
+		cid = uuid.Must(uuid.NewV4()).String()
 	}
+	ctx.Set(ContextKey, cid)
+
+	// echo the correlation ID so that clients can refer to the request
+	ctx.Header(m.header, cid)
 
 	ctx.Next()
 }
@@ -0,0 +1,71 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareCorrelationId(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestId string
+		check     func(respHeader string, ctxValue string)
+	}{
+		{
+			name:      "request ID taken from the request",
+			requestId: "3f6a2c8e-request",
+			check: func(respHeader string, ctxValue string) {
+				assert.Equal(t, "3f6a2c8e-request", respHeader)
+				assert.Equal(t, "3f6a2c8e-request", ctxValue)
+			},
+		},
+		{
+			name:      "request ID generated",
+			requestId: "",
+			check: func(respHeader string, ctxValue string) {
+				assert.NotEmpty(t, respHeader)
+				assert.Equal(t, respHeader, ctxValue)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(MiddlewareCorrelationId(Header("X-Request-ID")))
+
+			var ctxValue string
+			router.GET("/status", func(c *gin.Context) {
+				ctxValue = c.GetString(ContextKey)
+				c.String(http.StatusOK, "ok")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/status", nil)
+			if test.requestId != "" {
+				req.Header.Set("X-Request-ID", test.requestId)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			test.check(w.Header().Get("X-Request-ID"), ctxValue)
+		})
+	}
+}
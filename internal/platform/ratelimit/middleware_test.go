@@ -0,0 +1,103 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(1, 2))
+	router.POST("/cluster", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	var codes []int
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/cluster", nil)
+		req.RemoteAddr = "10.0.0.1:12345"
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		codes = append(codes, resp.Code)
+		if resp.Code == http.StatusTooManyRequests {
+			assert.Equal(t, "1", resp.Header().Get("Retry-After"))
+		}
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests}, codes)
+}
+
+func TestLimiter_allow(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(l *limiter, clock *time.Time)
+	}{
+		{
+			name: "tokens refilled by the rate",
+			check: func(l *limiter, clock *time.Time) {
+				assert.True(t, allowed(l, "client"))
+				assert.True(t, allowed(l, "client"))
+				ok, wait := l.allow("client")
+				assert.False(t, ok)
+				assert.Equal(t, 500*time.Millisecond, wait)
+
+				*clock = clock.Add(500 * time.Millisecond)
+				assert.True(t, allowed(l, "client"))
+				assert.False(t, allowed(l, "client"))
+			},
+		},
+		{
+			name: "clients limited separately",
+			check: func(l *limiter, clock *time.Time) {
+				assert.True(t, allowed(l, "client-1"))
+				assert.True(t, allowed(l, "client-1"))
+				assert.False(t, allowed(l, "client-1"))
+				assert.True(t, allowed(l, "client-2"))
+			},
+		},
+		{
+			name: "idle clients purged",
+			check: func(l *limiter, clock *time.Time) {
+				assert.True(t, allowed(l, "client-1"))
+				*clock = clock.Add(time.Second)
+				assert.True(t, allowed(l, "client-2"))
+				assert.Equal(t, 1, len(l.buckets))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+			l := newLimiter(2, 2)
+			l.now = func() time.Time { return clock }
+
+			test.check(l, &clock)
+		})
+	}
+}
+
+func allowed(l *limiter, key string) bool {
+	ok, _ := l.allow(key)
+	return ok
+}
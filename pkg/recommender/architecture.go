@@ -0,0 +1,83 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"fmt"
+	"sort"
+)
+
+// groupByArchitecture groups the vms by their cpu architecture, the architectures are returned in alphabetical order
+func groupByArchitecture(vms []VirtualMachine) ([]string, map[string][]VirtualMachine) {
+	groups := make(map[string][]VirtualMachine)
+	for _, vm := range vms {
+		arch := vm.Architecture
+		if arch == "" {
+			arch = X86Architecture
+		}
+		groups[arch] = append(groups[arch], vm)
+	}
+
+	archs := make([]string, 0, len(groups))
+	for arch := range groups {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+
+	return archs, groups
+}
+
+// recommendArchitectures recommends a cluster from the vms of each cpu architecture independently
+// the cheapest one is returned as the recommendation, all of them are listed per architecture
+func (e *Engine) recommendArchitectures(provider string, service string, region string, req ClusterRecommendationReq,
+	currency string, rate float64, archs []string, groups map[string][]VirtualMachine) (*ClusterRecommendationResp, error) {
+	var (
+		cheapest        *ClusterRecommendationResp
+		recommendations []ArchitectureRecommendation
+		warnings        []string
+		firstErr        error
+	)
+
+	for _, arch := range archs {
+		resp, err := e.recommendCluster(provider, service, region, req, nil, currency, rate, groups[arch])
+		if err != nil {
+			e.log.Warn("failed to recommend cluster for architecture", map[string]interface{}{"architecture": arch, "error": err.Error()})
+			warnings = append(warnings, fmt.Sprintf("no recommendation for the %s architecture: %s", arch, err))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		recommendations = append(recommendations, ArchitectureRecommendation{
+			Architecture: arch,
+			NodePools:    resp.NodePools,
+			Accuracy:     resp.Accuracy,
+			Warnings:     resp.Warnings,
+		})
+		if cheapest == nil || resp.Accuracy.RecTotalPrice < cheapest.Accuracy.RecTotalPrice {
+			cheapest = resp
+		}
+	}
+
+	if cheapest == nil {
+		return nil, firstErr
+	}
+
+	cheapest.Warnings = append(cheapest.Warnings, warnings...)
+	cheapest.Architectures = recommendations
+
+	return cheapest, nil
+}
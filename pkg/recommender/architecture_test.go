@@ -0,0 +1,66 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"testing"
+
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_RecommendClusterGroupByArchitecture(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, Architecture: X86Architecture},
+		{Type: "m6g.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.154, Architecture: ArmArchitecture},
+	}
+	tests := []struct {
+		name    string
+		groupBy bool
+		check   func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:    "single recommendation by default",
+			groupBy: false,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m6g.xlarge", resp.NodePools[0].VmType.Type)
+				assert.Nil(t, resp.Architectures)
+			},
+		},
+		{
+			name:    "a recommendation per architecture",
+			groupBy: true,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m6g.xlarge", resp.NodePools[0].VmType.Type)
+				assert.Len(t, resp.Architectures, 2)
+				assert.Equal(t, ArmArchitecture, resp.Architectures[0].Architecture)
+				assert.Equal(t, "m6g.xlarge", resp.Architectures[0].NodePools[0].VmType.Type)
+				assert.Equal(t, X86Architecture, resp.Architectures[1].Architecture)
+				assert.Equal(t, "m5.xlarge", resp.Architectures[1].NodePools[0].VmType.Type)
+				assert.Equal(t, 2, resp.Architectures[1].Accuracy.RecNodes)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &cheapestOnDemandPool{}, nil, EngineConfig{})
+
+			req := ClusterRecommendationReq{MinNodes: 2, MaxNodes: 2, SumCpu: 8, SumMem: 32, OnDemandPct: 100, GroupByArchitecture: test.groupBy}
+			test.check(engine.RecommendCluster("amazon", "compute", "eu-west-1", req, nil))
+		})
+	}
+}
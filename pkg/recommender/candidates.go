@@ -0,0 +1,31 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import "sort"
+
+// capCandidates keeps the cheapest max of the vms by the given price per unit of the attribute, all of them if max is 0
+// the node pools are priced by the same price, so the cheapest candidates of the cost ranking are never dropped
+func capCandidates(attr string, vms []VirtualMachine, max int, price func(vm VirtualMachine) float64) []VirtualMachine {
+	if max <= 0 || len(vms) <= max {
+		return vms
+	}
+
+	capped := append([]VirtualMachine(nil), vms...)
+	sort.SliceStable(capped, func(i, j int) bool {
+		return price(capped[i])/capped[i].GetAttrValue(attr) < price(capped[j])/capped[j].GetAttrValue(attr)
+	})
+	return capped[:max]
+}
@@ -0,0 +1,61 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"math"
+)
+
+// weightNodePools sizes the node pools by vcpu-weighted capacity the way the spot fleets allocate a capacity target:
+// every node weighs its cpus, the on-demand share of the requested cpus is allocated to the regular node pools
+// and the rest is spread evenly across the spot node pools the node count model put nodes in
+func weightNodePools(req ClusterRecommendationReq, nodePools []NodePool) {
+	var regular, spot []int
+	for i, np := range nodePools {
+		nodePools[i].Weight = np.VmType.Cpus
+		switch {
+		case np.VmClass == Regular:
+			regular = append(regular, i)
+		case np.SumNodes > 0:
+			spot = append(spot, i)
+		}
+	}
+
+	onDemandCapacity := allocateCapacity(req.SumCpu*float64(req.OnDemandPct)/100, regular, nodePools)
+	allocateCapacity(req.SumCpu-onDemandCapacity, spot, nodePools)
+}
+
+// allocateCapacity spreads the capacity target evenly across the node pools with the given indexes
+// every pool gets as many nodes as needed to cover its share, the allocated capacity is returned
+// the pools without weight (eg. no cpus reported for the instance type) can't cover a share, they are left untouched
+func allocateCapacity(target float64, pools []int, nodePools []NodePool) float64 {
+	var weighted []int
+	for _, i := range pools {
+		if nodePools[i].Weight > 0 {
+			weighted = append(weighted, i)
+		}
+	}
+	if len(weighted) == 0 {
+		return 0
+	}
+
+	var allocated float64
+	share := math.Max(target, 0) / float64(len(weighted))
+	for _, i := range weighted {
+		nodePools[i].SumNodes = int(math.Ceil(share / nodePools[i].Weight))
+		allocated += float64(nodePools[i].SumNodes) * nodePools[i].Weight
+	}
+	return allocated
+}
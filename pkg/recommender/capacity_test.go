@@ -0,0 +1,107 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"testing"
+
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedNodePools recommends a regular m5.xlarge pool and spot pools of different sizes by the node count model
+type fixedNodePools struct{}
+
+func (nps *fixedNodePools) RecommendNodePools(attr string, req ClusterRecommendationReq, layout []NodePool, odVms []VirtualMachine, spotVms []VirtualMachine) []NodePool {
+	return []NodePool{
+		{VmType: VirtualMachine{Type: "m5.xlarge", Cpus: 4, OnDemandPrice: 0.192}, SumNodes: 1, VmClass: Regular, Role: Worker},
+		{VmType: VirtualMachine{Type: "m5.xlarge", Cpus: 4, AvgPrice: 0.07}, SumNodes: 2, VmClass: Spot, Role: Worker},
+		{VmType: VirtualMachine{Type: "m5.4xlarge", Cpus: 16, AvgPrice: 0.28}, SumNodes: 1, VmClass: Spot, Role: Worker},
+		{VmType: VirtualMachine{Type: "c5.xlarge", Cpus: 4, AvgPrice: 0.065}, SumNodes: 0, VmClass: Spot, Role: Worker},
+	}
+}
+
+func TestEngine_RecommendClusterCapacityModel(t *testing.T) {
+	products := fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.07}}
+	tests := []struct {
+		name  string
+		model string
+		check func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name: "node count by default",
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []int{1, 2, 1, 0}, sumNodes(resp.NodePools))
+				assert.Equal(t, 28.0, resp.Accuracy.RecCpu)
+				for _, np := range resp.NodePools {
+					assert.Zero(t, np.Weight)
+				}
+			},
+		},
+		{
+			name:  "vcpu-weighted",
+			model: VcpuWeightedCapacity,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				// 4 regular cpus, the remaining 24 spread across the spot pools with nodes: 12 each
+				assert.Equal(t, []int{1, 3, 1, 0}, sumNodes(resp.NodePools))
+				assert.Equal(t, []float64{4, 4, 16, 4}, []float64{resp.NodePools[0].Weight, resp.NodePools[1].Weight, resp.NodePools[2].Weight, resp.NodePools[3].Weight})
+				assert.Equal(t, 32.0, resp.Accuracy.RecCpu)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &fixedNodePools{}, nil, EngineConfig{})
+
+			req := ClusterRecommendationReq{MinNodes: 1, MaxNodes: 8, SumCpu: 28, SumMem: 112, OnDemandPct: 10, CapacityModel: test.model}
+			test.check(engine.RecommendCluster("amazon", "compute", "eu-west-1", req, nil))
+		})
+	}
+}
+
+func Test_allocateCapacityZeroWeight(t *testing.T) {
+	nodePools := []NodePool{
+		{VmType: VirtualMachine{Type: "m5.xlarge"}, Weight: 4, SumNodes: 1},
+		{VmType: VirtualMachine{Type: "unknown"}, Weight: 0, SumNodes: 2},
+	}
+
+	allocated := allocateCapacity(12, []int{0, 1}, nodePools)
+
+	assert.Equal(t, 12.0, allocated)
+	assert.Equal(t, []int{3, 2}, sumNodes(nodePools), "the pool without weight should be left untouched")
+}
+
+func TestEngine_RecommendClusterCapacityModelNodeCount(t *testing.T) {
+	products := fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.07}}
+	engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &fixedNodePools{}, nil, EngineConfig{})
+
+	req := ClusterRecommendationReq{NodeCount: 6, OnDemandPct: 10, CapacityModel: VcpuWeightedCapacity}
+	resp, err := engine.RecommendCluster("amazon", "compute", "eu-west-1", req, nil)
+
+	assert.EqualError(t, err, "the vcpu-weighted capacity model needs sumCpu, it can't be requested by nodeCount")
+	assert.Contains(t, emperror.Context(err), RecommenderErrorTag)
+	assert.Nil(t, resp)
+}
+
+func sumNodes(nodePools []NodePool) []int {
+	nodes := make([]int, 0, len(nodePools))
+	for _, np := range nodePools {
+		nodes = append(nodes, np.SumNodes)
+	}
+	return nodes
+}
@@ -0,0 +1,48 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import "sort"
+
+// carbonIntensity returns the configured carbon intensity of the region, nil if the region is not in the dataset
+func (e *Engine) carbonIntensity(region string) *float64 {
+	intensity, ok := e.config.CarbonIntensities[region]
+	if !ok {
+		return nil
+	}
+	return &intensity
+}
+
+// limitByCarbonIntensity orders the responses by the carbon intensity of their regions and keeps the first respPerService of them
+// the regions without a known intensity come last, equal intensities are ordered by price
+func limitByCarbonIntensity(responses []*ClusterRecommendationResp, respPerService int) []*ClusterRecommendationResp {
+	sort.SliceStable(responses, func(i, j int) bool {
+		ci, cj := responses[i].CarbonIntensity, responses[j].CarbonIntensity
+		switch {
+		case ci != nil && cj == nil:
+			return true
+		case ci == nil && cj != nil:
+			return false
+		case ci != nil && *ci != *cj:
+			return *ci < *cj
+		}
+		return responses[i].Accuracy.RecTotalPrice < responses[j].Accuracy.RecTotalPrice
+	})
+
+	if respPerService > 0 && len(responses) > respPerService {
+		responses = responses[:respPerService]
+	}
+	return responses
+}
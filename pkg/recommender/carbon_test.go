@@ -0,0 +1,100 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+// pricedRegions returns the same instance type in every region of a single continent, priced per region
+type pricedRegions map[string]float64
+
+func (p pricedRegions) GetProductDetails(provider string, service string, region string) ([]VirtualMachine, error) {
+	return []VirtualMachine{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: p[region], AvgPrice: p[region]}}, nil
+}
+
+func (p pricedRegions) GetRegions(provider, service string) ([]*models.Continent, error) {
+	continent := &models.Continent{Name: "Europe"}
+	for region := range p {
+		continent.Regions = append(continent.Regions, &models.Region{ID: region})
+	}
+	return []*models.Continent{continent}, nil
+}
+
+func TestEngine_RecommendMultiClusterCarbon(t *testing.T) {
+	products := pricedRegions{"eu-west-1": 0.1, "eu-central-1": 0.2, "eu-north-1": 0.3, "eu-south-1": 0.05}
+	intensities := map[string]float64{"eu-west-1": 300, "eu-central-1": 350, "eu-north-1": 30}
+	tests := []struct {
+		name      string
+		objective string
+		check     func(resps []*ClusterRecommendationResp)
+	}{
+		{
+			name:      "regions ranked by price by default",
+			objective: Cost,
+			check: func(resps []*ClusterRecommendationResp) {
+				assert.Equal(t, []string{"eu-south-1", "eu-west-1", "eu-central-1", "eu-north-1"}, regionsOf(resps))
+			},
+		},
+		{
+			name:      "regions ranked by carbon intensity, unknown ones last",
+			objective: Carbon,
+			check: func(resps []*ClusterRecommendationResp) {
+				assert.Equal(t, []string{"eu-north-1", "eu-west-1", "eu-central-1", "eu-south-1"}, regionsOf(resps))
+				assert.Equal(t, 30.0, *resps[0].CarbonIntensity)
+				assert.Nil(t, resps[3].CarbonIntensity)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &cheapestOnDemandPool{}, nil, EngineConfig{CarbonIntensities: intensities})
+			resps, err := engine.RecommendMultiCluster(MultiClusterRecommendationReq{
+				Providers:  []Provider{{Provider: "amazon", Services: []string{"compute"}}},
+				Continents: []string{"Europe"},
+				ClusterRecommendationReq: ClusterRecommendationReq{
+					SumCpu: 4, SumMem: 16, MinNodes: 1, MaxNodes: 1, OnDemandPct: 100, Objective: test.objective,
+				},
+				RespPerService: 4,
+			})
+			assert.Nil(t, err, "the error should be nil")
+			test.check(resps["amazonCOMPUTE"])
+		})
+	}
+}
+
+func Test_limitByCarbonIntensity(t *testing.T) {
+	low, high := 30.0, 400.0
+	resps := []*ClusterRecommendationResp{
+		{Region: "unknown", Accuracy: ClusterRecommendationAccuracy{RecTotalPrice: 0.1}},
+		{Region: "high", CarbonIntensity: &high, Accuracy: ClusterRecommendationAccuracy{RecTotalPrice: 0.1}},
+		{Region: "low-pricey", CarbonIntensity: &low, Accuracy: ClusterRecommendationAccuracy{RecTotalPrice: 0.3}},
+		{Region: "low-cheap", CarbonIntensity: &low, Accuracy: ClusterRecommendationAccuracy{RecTotalPrice: 0.2}},
+	}
+
+	assert.Equal(t, []string{"low-cheap", "low-pricey"}, regionsOf(limitByCarbonIntensity(resps, 2)))
+}
+
+func regionsOf(resps []*ClusterRecommendationResp) []string {
+	regions := make([]string, len(resps))
+	for i, resp := range resps {
+		regions[i] = resp.Region
+	}
+	return regions
+}
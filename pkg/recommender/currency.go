@@ -0,0 +1,85 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultCurrency is the currency the prices are provided in by the cloud info service
+const DefaultCurrency = "USD"
+
+// currencyRate returns the currency code and the rate the prices are converted by for the requested currency
+func (e *Engine) currencyRate(currency string) (string, float64, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "" || currency == DefaultCurrency {
+		return DefaultCurrency, 1, nil
+	}
+
+	rate, ok := e.config.CurrencyRates[currency]
+	if !ok {
+		return "", 0, errors.Errorf("no conversion rate configured for currency %s", currency)
+	}
+
+	return currency, rate, nil
+}
+
+// convertPrices converts the prices of the instance types in the node pools by the given rate
+func convertPrices(nodePools []NodePool, rate float64) {
+	if rate == 1 {
+		return
+	}
+
+	for i := range nodePools {
+		vm := &nodePools[i].VmType
+		vm.OnDemandPrice *= rate
+		vm.AvgPrice *= rate
+
+		if vm.ZonePrices != nil {
+			// the zone prices map is shared with the product details, it's not modified in place
+			zonePrices := make(map[string]float64, len(vm.ZonePrices))
+			for zone, price := range vm.ZonePrices {
+				zonePrices[zone] = price * rate
+			}
+			vm.ZonePrices = zonePrices
+		}
+	}
+}
+
+// WithNormalizedPrices returns a copy of the vm with its on-demand and spot prices normalized per cpu and per GB of memory
+func WithNormalizedPrices(vm VirtualMachine) VirtualMachine {
+	vm.OnDemandPricePerVcpu = pricePerUnit(vm.OnDemandPrice, vm.Cpus)
+	vm.OnDemandPricePerGb = pricePerUnit(vm.OnDemandPrice, vm.Mem)
+	vm.SpotPricePerVcpu = pricePerUnit(vm.AvgPrice, vm.Cpus)
+	vm.SpotPricePerGb = pricePerUnit(vm.AvgPrice, vm.Mem)
+	return vm
+}
+
+// pricePerUnit divides the price by the units, 0 if there are no units
+func pricePerUnit(price, units float64) float64 {
+	if units <= 0 {
+		return 0
+	}
+	return price / units
+}
+
+// normalizePrices sets the normalized prices of the instance types in the node pools
+func normalizePrices(nodePools []NodePool) {
+	for i := range nodePools {
+		nodePools[i].VmType = WithNormalizedPrices(nodePools[i].VmType)
+	}
+}
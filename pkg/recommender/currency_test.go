@@ -0,0 +1,67 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithNormalizedPrices(t *testing.T) {
+	tests := []struct {
+		name  string
+		vm    VirtualMachine
+		check func(vm VirtualMachine)
+	}{
+		{
+			name: "prices divided by the resources",
+			vm:   VirtualMachine{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.08},
+			check: func(vm VirtualMachine) {
+				assert.Equal(t, 0.192/4, vm.OnDemandPricePerVcpu)
+				assert.Equal(t, 0.192/16, vm.OnDemandPricePerGb)
+				assert.Equal(t, 0.08/4, vm.SpotPricePerVcpu)
+				assert.Equal(t, 0.08/16, vm.SpotPricePerGb)
+				assert.Equal(t, 0.192, vm.OnDemandPrice, "the raw prices should be kept")
+			},
+		},
+		{
+			name: "no spot price",
+			vm:   VirtualMachine{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192},
+			check: func(vm VirtualMachine) {
+				assert.Equal(t, 0.048, vm.OnDemandPricePerVcpu)
+				assert.Zero(t, vm.SpotPricePerVcpu)
+				assert.Zero(t, vm.SpotPricePerGb)
+			},
+		},
+		{
+			name: "no resources",
+			vm:   VirtualMachine{Type: "exotic", OnDemandPrice: 0.192, AvgPrice: 0.08},
+			check: func(vm VirtualMachine) {
+				assert.Zero(t, vm.OnDemandPricePerVcpu)
+				assert.Zero(t, vm.OnDemandPricePerGb)
+				assert.Zero(t, vm.SpotPricePerVcpu)
+				assert.Zero(t, vm.SpotPricePerGb)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vm := test.vm
+			test.check(WithNormalizedPrices(vm))
+			assert.Zero(t, vm.OnDemandPricePerVcpu, "the vm should not be modified")
+		})
+	}
+}
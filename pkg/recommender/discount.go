@@ -0,0 +1,69 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"sort"
+	"strings"
+)
+
+// applyOnDemandDiscounts reduces the on-demand prices of the vms by the discount (percentage) of their instance family
+func applyOnDemandDiscounts(discounts map[string]float64, vms []VirtualMachine) {
+	for i := range vms {
+		if discount, ok := discounts[instanceFamily(vms[i].Type, discounts)]; ok {
+			vms[i].OnDemandPrice *= 1 - discount/100
+		}
+	}
+}
+
+// instanceFamily returns the most specific family among the given ones the instance type belongs to, empty if none
+// a type belongs to a family if it starts with the family followed by a separator, eg. m5.xlarge to m5 but not m5a.xlarge
+func instanceFamily(vmType string, families map[string]float64) string {
+	var found string
+	for family := range families {
+		if len(family) > len(found) && len(vmType) > len(family) && strings.HasPrefix(vmType, family) &&
+			strings.ContainsRune(".-_", rune(vmType[len(family)])) {
+			found = family
+		}
+	}
+	return found
+}
+
+// applySpotFallback prices the vms without a spot price at their on-demand price multiplied by the multiplier
+// it returns the instance types priced by the fallback
+func applySpotFallback(multiplier float64, vms []VirtualMachine) map[string]bool {
+	fallbacks := make(map[string]bool)
+	for i := range vms {
+		if vms[i].AvgPrice == 0 && vms[i].OnDemandPrice > 0 {
+			vms[i].AvgPrice = vms[i].OnDemandPrice * multiplier
+			fallbacks[vms[i].Type] = true
+		}
+	}
+	return fallbacks
+}
+
+// spotFallbackTypes returns the instance types of the spot node pools priced by the spot fallback in alphabetical order
+func spotFallbackTypes(fallbacks map[string]bool, nodePools []NodePool) []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, np := range nodePools {
+		if np.VmClass == Spot && fallbacks[np.VmType.Type] && !seen[np.VmType.Type] {
+			seen[np.VmType.Type] = true
+			types = append(types, np.VmType.Type)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
@@ -15,10 +15,12 @@
 package recommender
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/goph/emperror"
 	"github.com/goph/logur"
@@ -27,31 +29,185 @@ import (
 
 // Engine represents the recommendation engine, it operates on a map of provider -> VmRegistry
 type Engine struct {
-	log              logur.Logger
-	ciSource         CloudInfoSource
-	vmSelector       VmRecommender
-	nodePoolSelector NodePoolRecommender
+	log                logur.Logger
+	ciSource           CloudInfoSource
+	vmSelector         VmRecommender
+	nodePoolSelector   NodePoolRecommender
+	interruptionSource InterruptionSource
+	config             EngineConfig
+	now                func() time.Time
+	// the context the product details are retrieved within
+	ctx context.Context
 }
 
-// NewEngine creates a new Engine instance
-func NewEngine(log logur.Logger, ciSource CloudInfoSource, vmSelector VmRecommender, nodePoolSelector NodePoolRecommender) *Engine {
+// EngineConfig holds the optional settings of the recommendation engine
+type EngineConfig struct {
+	// conversion rates of the USD prices keyed by currency code
+	CurrencyRates map[string]float64
+	// number of hours the monthly costs are estimated for, DefaultHoursPerMonth if not set
+	HoursPerMonth float64
+	// availability zones keyed by region the recommendations are restricted to if the request doesn't specify any
+	// all zones of a region are used if it has no default zones
+	DefaultZones map[string][]string
+	// age of the prices the recommendations are warned to be based on stale prices after, no warning if 0
+	MaxPriceAge time.Duration
+	// relative carbon intensities of the electricity keyed by region, eg. in gCO2eq/kWh
+	// the recommendations of the regions are annotated with them and the multi-cluster ones ranked by them on request
+	CarbonIntensities map[string]float64
+	// maximum number of the cheapest instance types per attribute the regular and the spot node pools are selected from, no limit if 0
+	// it bounds the work of broad requests matching lots of instance types
+	MaxCandidates int
+	// maximum number of distinct zones (and preferred zones) a request can list, DefaultMaxZones if not set
+	MaxZones int
+	// custom ranking of the spot instance types replacing the objective of the requests, if the node pool selector supports it
+	Scorer Scorer
+}
+
+// NewEngine creates a new Engine instance, the interruption source is optional
+func NewEngine(log logur.Logger, ciSource CloudInfoSource, vmSelector VmRecommender, nodePoolSelector NodePoolRecommender, interruptionSource InterruptionSource, config EngineConfig) *Engine {
+	if config.HoursPerMonth <= 0 {
+		config.HoursPerMonth = DefaultHoursPerMonth
+	}
+	if config.MaxZones <= 0 {
+		config.MaxZones = DefaultMaxZones
+	}
+	if config.Scorer != nil {
+		if scoring, ok := nodePoolSelector.(ScoringNodePoolRecommender); ok {
+			nodePoolSelector = scoring.WithScorer(config.Scorer)
+		} else {
+			log.Warn("the node pool selector doesn't support custom scorers, the scorer is ignored")
+		}
+	}
+
 	return &Engine{
-		log:              log,
-		ciSource:         ciSource,
-		vmSelector:       vmSelector,
-		nodePoolSelector: nodePoolSelector,
+		log:                log,
+		ciSource:           ciSource,
+		vmSelector:         vmSelector,
+		nodePoolSelector:   nodePoolSelector,
+		interruptionSource: interruptionSource,
+		config:             config,
+		now:                time.Now,
+		ctx:                context.Background(),
 	}
 }
 
+// WithLogger returns a copy of the engine logging with the given logger
+func (e *Engine) WithLogger(log logur.Logger) ClusterRecommender {
+	engine := *e
+	engine.log = log
+	return &engine
+}
+
+// WithContext returns a copy of the engine retrieving the product details within the given context,
+// eg. to stop waiting for the Cloud Info service once the request is given up on
+func (e *Engine) WithContext(ctx context.Context) ClusterRecommender {
+	engine := *e
+	engine.ctx = ctx
+	return &engine
+}
+
 // RecommendCluster performs recommendation based on the provided arguments
 func (e *Engine) RecommendCluster(provider string, service string, region string, req ClusterRecommendationReq, layoutDesc []NodePoolDesc) (*ClusterRecommendationResp, error) {
 	e.log.Info(fmt.Sprintf("recommending cluster configuration. request: [%#v]", req))
 
-	allProducts, err := e.ciSource.GetProductDetails(provider, service, region)
+	req, err := resolveNodeSize(provider, req)
+	if err != nil {
+		return nil, emperror.With(err, RecommenderErrorTag, "nodeSize")
+	}
+
+	if err := e.ValidateClusterRecommendationReq(req); err != nil {
+		return nil, err
+	}
+
+	currency, rate, err := e.currencyRate(req.Currency)
+	if err != nil {
+		return nil, emperror.With(err, RecommenderErrorTag, "currency")
+	}
+
+	// the price band is compared to the prices before they are converted to the requested currency
+	req.MinPrice, req.MaxPrice = req.MinPrice/rate, req.MaxPrice/rate
+
+	if req.OnDemandOnly {
+		req.OnDemandPct = 100
+	}
+
+	if req.NodeCount > 0 {
+		req.MinNodes, req.MaxNodes = req.NodeCount, req.NodeCount
+	}
+
+	req.Zones, req.PreferZones = dedupeZones(req.Zones), dedupeZones(req.PreferZones)
+	requestedZones := req.Zones
+	if len(req.Zones) == 0 {
+		req.Zones = e.config.DefaultZones[region]
+	}
+
+	allProducts, err := ProductDetails(e.ctx, e.ciSource, provider, service, region)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.OnDemandPct < 100 && e.hasZones(provider) && !anyZones(allProducts) {
+		e.log.Warn("no availability zones in region", map[string]interface{}{"provider": provider, "region": region})
+		return nil, emperror.With(errors.Wrapf(ErrNoZones, "region %s", region), UnprocessableErrTag, "zones")
+	}
+
+	if invalid := zonesNotInRegion(requestedZones, allProducts); len(invalid) > 0 {
+		return nil, emperror.With(errors.Errorf("the zones %s are not availability zones of region %s", strings.Join(invalid, ", "), region),
+			RecommenderErrorTag, "zones")
+	}
+
+	if layoutDesc == nil {
+		if err := checkMemPerCpu(req, allProducts); err != nil {
+			return nil, emperror.With(err, RecommenderErrorTag, "memPerCpu")
+		}
+	}
+
+	if req.GroupByArchitecture && layoutDesc == nil {
+		if archs, groups := groupByArchitecture(allProducts); len(archs) > 1 {
+			return e.recommendArchitectures(provider, service, region, req, currency, rate, archs, groups)
+		}
+	}
+
+	return e.recommendCluster(provider, service, region, req, layoutDesc, currency, rate, allProducts)
+}
+
+// recommendCluster performs the recommendation from the given products
+func (e *Engine) recommendCluster(provider string, service string, region string, req ClusterRecommendationReq, layoutDesc []NodePoolDesc,
+	currency string, rate float64, allProducts []VirtualMachine) (*ClusterRecommendationResp, error) {
+	if len(req.OnDemandDiscounts) > 0 {
+		applyOnDemandDiscounts(req.OnDemandDiscounts, allProducts)
+	}
+
+	// degradations of the recommendation reported in the response
+	var warnings []string
+
+	if age, stale := e.priceAge(allProducts); stale {
+		e.log.Warn("stale prices", map[string]interface{}{"age": age.String()})
+		warnings = append(warnings, fmt.Sprintf("the prices were last updated %s ago, the recommendation may be based on stale prices", age))
+	}
+
+	if req.OnDemandPct < 100 && req.Objective == Stability {
+		if err := e.setInterruptionRatings(provider, region, allProducts); err != nil {
+			warnings = append(warnings, "spot interruption ratings are not available, the spot instance types are ranked by price")
+		}
+	}
+
+	if req.OnDemandPct < 100 {
+		for _, zone := range zonesWithoutSpotPrices(req.Zones, allProducts) {
+			e.log.Warn("no spot prices available in zone", map[string]interface{}{"zone": zone})
+			warnings = append(warnings, fmt.Sprintf("no spot prices available in zone %s, the spot prices of the other zones are used", zone))
+		}
+	}
+
+	if req.OnDemandPct < 100 && req.PriceStat != "" && req.PriceStat != AvgPriceStat {
+		setSpotPriceStats(req.PriceStat, allProducts)
+	}
+
+	var spotFallbacks map[string]bool
+	if req.OnDemandPct < 100 && req.SpotFallbackMultiplier > 0 {
+		spotFallbacks = applySpotFallback(req.SpotFallbackMultiplier, allProducts)
+	}
+
 	if req.OnDemandPct != 100 {
 		availableSpotPrice := false
 		for _, vm := range allProducts {
@@ -62,6 +218,7 @@ func (e *Engine) RecommendCluster(provider string, service string, region string
 		}
 		if !availableSpotPrice {
 			e.log.Warn("onDemand percentage in the request ignored")
+			warnings = append(warnings, "no spot prices available, only on-demand nodes are recommended")
 			req.OnDemandPct = 100
 		}
 	}
@@ -71,26 +228,329 @@ func (e *Engine) RecommendCluster(provider string, service string, region string
 		return nil, err
 	}
 
-	cheapestNodePoolSet, err := e.getCheapestNodePoolSet(provider, req, layoutDesc, allProducts)
+	var cheapestNodePoolSet []NodePool
+	if req.NodeCount > 0 && layoutDesc == nil {
+		cheapestNodePoolSet, err = e.recommendNodeCount(provider, req, allProducts)
+	} else if req.Tiered && layoutDesc == nil {
+		var tierWarnings []string
+		cheapestNodePoolSet, tierWarnings, err = e.recommendTiers(provider, req, allProducts)
+		warnings = append(warnings, tierWarnings...)
+	} else {
+		cheapestNodePoolSet, err = e.getCheapestNodePoolSet(provider, req, layoutDesc, allProducts)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if req.CapacityModel == VcpuWeightedCapacity && layoutDesc == nil {
+		weightNodePools(req, cheapestNodePoolSet)
+	}
+	for _, vmType := range spotFallbackTypes(spotFallbacks, cheapestNodePoolSet) {
+		warnings = append(warnings, fmt.Sprintf("no spot price available for %s, it's priced at %v times its on-demand price", vmType, req.SpotFallbackMultiplier))
+	}
 	if cheapestMaster != nil {
 		cheapestNodePoolSet = append(cheapestNodePoolSet, *cheapestMaster)
 	}
 
+	setCheapestZones(req.Zones, cheapestNodePoolSet)
+	if len(req.PreferZones) > 0 {
+		setPreferredZones(req.Zones, req.PreferZones, cheapestNodePoolSet)
+	}
+	convertPrices(cheapestNodePoolSet, rate)
+	normalizePrices(cheapestNodePoolSet)
+	e.setMonthlyCosts(cheapestNodePoolSet)
+	if !req.IncludeRawPrices {
+		removeZonePrices(cheapestNodePoolSet)
+	}
+
 	accuracy := findResponseSum(req.Zones, cheapestNodePoolSet)
 
+	var perZone map[string]NodePool
+	if req.PerZone && layoutDesc == nil && req.OnDemandPct == 100 {
+		warnings = append(warnings, "only on-demand nodes are recommended, no spot node pools per zone")
+	} else if req.PerZone && layoutDesc == nil {
+		var zoneWarnings []string
+		perZone, zoneWarnings, err = e.recommendPerZone(provider, req, allProducts)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, zoneWarnings...)
+		e.finishZoneNodePools(perZone, rate, req.IncludeRawPrices)
+	}
+
+	var explanation []RejectedVm
+	if req.Explain && layoutDesc == nil && req.NodeCount == 0 {
+		explanation, err = e.explain(provider, req, allProducts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &ClusterRecommendationResp{
-		Provider:  provider,
-		Service:   service,
-		Region:    region,
-		Zones:     req.Zones,
-		NodePools: cheapestNodePoolSet,
-		Accuracy:  accuracy,
+		Provider:        provider,
+		Service:         service,
+		Region:          region,
+		Zones:           req.Zones,
+		NodePools:       cheapestNodePoolSet,
+		Accuracy:        accuracy,
+		Currency:        currency,
+		CarbonIntensity: e.carbonIntensity(region),
+		Warnings:        warnings,
+		Explanation:     explanation,
+		PerZone:         perZone,
 	}, nil
 }
 
+// ValidateClusterRecommendationReq checks the request against the rules of the recommendation without performing it
+func (e *Engine) ValidateClusterRecommendationReq(req ClusterRecommendationReq) error {
+	if err := checkTarget(req); err != nil {
+		return emperror.With(err, RecommenderErrorTag, "target")
+	}
+
+	if err := checkNodeBounds(req); err != nil {
+		return emperror.With(err, RecommenderErrorTag, "nodes")
+	}
+
+	if _, _, err := e.currencyRate(req.Currency); err != nil {
+		return emperror.With(err, RecommenderErrorTag, "currency")
+	}
+
+	if err := checkZones(req, e.config.MaxZones); err != nil {
+		return emperror.With(err, RecommenderErrorTag, "zones")
+	}
+
+	return nil
+}
+
+// Capabilities describes the request options supported on the known providers
+func (e *Engine) Capabilities() []ProviderCapabilities {
+	return e.vmSelector.Capabilities()
+}
+
+// checkTarget checks that the request targets either the total cpus and memory of the cluster or the number of nodes
+func checkTarget(req ClusterRecommendationReq) error {
+	capacity := req.SumCpu > 0 || req.SumMem > 0
+	switch {
+	case capacity && req.NodeCount > 0:
+		return errors.New("either sumCpu and sumMem or nodeCount can be requested, not both")
+	case req.Tiered && req.NodeCount > 0:
+		return errors.New("tiered node pools can't be requested by nodeCount")
+	case req.CapacityModel == VcpuWeightedCapacity && req.NodeCount > 0:
+		return errors.New("the vcpu-weighted capacity model needs sumCpu, it can't be requested by nodeCount")
+	case req.NodeCount > 0:
+		return nil
+	case req.SumCpu <= 0 || req.SumMem <= 0:
+		return errors.New("either sumCpu and sumMem or nodeCount must be requested")
+	case req.MinNodes < 1:
+		return errors.New("minNodes must be at least 1 if sumCpu and sumMem are requested")
+	}
+	return nil
+}
+
+// checkNodeBounds checks whether the node count and the per node cpu and memory bounds in the request can be satisfied together
+func checkNodeBounds(req ClusterRecommendationReq) error {
+	if req.MaxVcpu > 0 && req.MinVcpu > req.MaxVcpu {
+		return errors.Errorf("minVcpu (%v) is greater than maxVcpu (%v)", req.MinVcpu, req.MaxVcpu)
+	}
+	if req.MaxVcpu > 0 && req.MaxNodes > 0 && req.MaxVcpu*float64(req.MaxNodes) < req.SumCpu {
+		return errors.Errorf("%d nodes with at most %v cpus can't provide the requested %v cpus", req.MaxNodes, req.MaxVcpu, req.SumCpu)
+	}
+	if req.MaxPrice > 0 && req.MinPrice > req.MaxPrice {
+		return errors.Errorf("minPrice (%v) is greater than maxPrice (%v)", req.MinPrice, req.MaxPrice)
+	}
+	if req.MaxMem > 0 && req.MinMem > req.MaxMem {
+		return errors.Errorf("minMem (%v) is greater than maxMem (%v)", req.MinMem, req.MaxMem)
+	}
+	if req.MaxMem > 0 && req.MaxNodes > 0 && req.MaxMem*float64(req.MaxNodes) < req.SumMem {
+		return errors.Errorf("%d nodes with at most %v GB memory can't provide the requested %v GB", req.MaxNodes, req.MaxMem, req.SumMem)
+	}
+	return nil
+}
+
+// memPerCpuTolerance is how many times the requested memory a recommendation may provision
+// before the memory per cpu ratio of the request is considered nonsensical
+const memPerCpuTolerance = 2
+
+// checkMemPerCpu rejects requests with a memory per cpu ratio no instance type in the region comes close to:
+// satisfying them would take more than memPerCpuTolerance times the requested memory
+func checkMemPerCpu(req ClusterRecommendationReq, vms []VirtualMachine) error {
+	minMemPerCpu := math.Inf(1)
+	for _, vm := range vms {
+		if vm.Cpus > 0 {
+			minMemPerCpu = math.Min(minMemPerCpu, vm.Mem/vm.Cpus)
+		}
+	}
+	if math.IsInf(minMemPerCpu, 1) || req.SumCpu <= 0 {
+		return nil
+	}
+
+	if memPerCpu := req.SumMem / req.SumCpu; memPerCpu*memPerCpuTolerance < minMemPerCpu {
+		return errors.Errorf("the requested %v GB memory for %v cpus is %.3g GB per cpu, but the instance types in the region have at least %.3g GB per cpu - request at least %.3g GB memory",
+			req.SumMem, req.SumCpu, memPerCpu, minMemPerCpu, minMemPerCpu*req.SumCpu)
+	}
+	return nil
+}
+
+// PricesUpdated returns the time the latest prices of the vms were updated, zero if they don't carry it
+func PricesUpdated(vms []VirtualMachine) time.Time {
+	var updated time.Time
+	for _, vm := range vms {
+		if vm.PricesUpdated.After(updated) {
+			updated = vm.PricesUpdated
+		}
+	}
+	return updated
+}
+
+// priceAge returns the age of the latest prices of the vms and whether it exceeds the configured maximum
+// the age is unknown (0) if the vms don't carry the time their prices were updated
+func (e *Engine) priceAge(vms []VirtualMachine) (time.Duration, bool) {
+	updated := PricesUpdated(vms)
+	if updated.IsZero() {
+		return 0, false
+	}
+
+	age := e.now().Sub(updated).Round(time.Minute)
+	return age, e.config.MaxPriceAge > 0 && age > e.config.MaxPriceAge
+}
+
+// setInterruptionRatings sets the spot interruption ratings on the vms
+// if the ratings are not available the vms are ranked by their prices only and the error is returned
+func (e *Engine) setInterruptionRatings(provider, region string, vms []VirtualMachine) error {
+	if e.interruptionSource == nil {
+		return nil
+	}
+
+	ratings, err := e.interruptionSource.GetInterruptionRatings(provider, region)
+	if err != nil {
+		e.log.Warn("interruption ratings are not available", map[string]interface{}{"error": err.Error()})
+		return err
+	}
+
+	for i := range vms {
+		vms[i].InterruptionRating = ratings[vms[i].Type]
+	}
+	return nil
+}
+
+// ErrNoZones is returned if the region of a provider with availability zones has none, eg. a brand-new or restricted region
+var ErrNoZones = errors.New("no availability zones available, the region may be new or restricted")
+
+// hasZones checks whether the provider has availability zones according to its capabilities
+func (e *Engine) hasZones(provider string) bool {
+	for _, c := range e.vmSelector.Capabilities() {
+		if c.Provider == provider {
+			return c.Zones
+		}
+	}
+	return false
+}
+
+// anyZones checks whether any of the vms is available in an availability zone
+func anyZones(vms []VirtualMachine) bool {
+	for _, vm := range vms {
+		if len(vm.Zones) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// zonesNotInRegion collects the zones none of the vms of the region is available in
+// it only reports zones if the vms are available in any zones at all
+func zonesNotInRegion(zones []string, vms []VirtualMachine) []string {
+	regionZones := make(map[string]bool)
+	for _, vm := range vms {
+		for _, zone := range vm.Zones {
+			regionZones[zone] = true
+		}
+	}
+	if len(regionZones) == 0 {
+		return nil
+	}
+
+	var invalid []string
+	for _, zone := range zones {
+		if !regionZones[zone] {
+			invalid = append(invalid, zone)
+		}
+	}
+	return invalid
+}
+
+// zonesWithoutSpotPrices collects the requested zones none of the vms has a spot price in
+// it only reports zones if there are spot prices available per zone at all
+func zonesWithoutSpotPrices(zones []string, vms []VirtualMachine) []string {
+	pricedZones := make(map[string]bool)
+	for _, vm := range vms {
+		for zone := range vm.ZonePrices {
+			pricedZones[zone] = true
+		}
+	}
+	if len(pricedZones) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for _, zone := range zones {
+		if !pricedZones[zone] {
+			missing = append(missing, zone)
+		}
+	}
+	return missing
+}
+
+// setSpotPriceStats prices the spot instance types by the given statistic of their zone spot prices instead of the average
+func setSpotPriceStats(stat string, vms []VirtualMachine) {
+	for i := range vms {
+		if len(vms[i].ZonePrices) > 0 {
+			vms[i].AvgPrice = spotPriceStat(vms[i].ZonePrices, stat)
+		}
+	}
+}
+
+// explain collects the instance types filtered out for any of the recommendation attributes
+func (e *Engine) explain(provider string, req ClusterRecommendationReq, allProducts []VirtualMachine) ([]RejectedVm, error) {
+	var explanation []RejectedVm
+	for _, attr := range []string{Cpu, Memory} {
+		rejected, err := e.vmSelector.ExplainVms(provider, attr, req, allProducts)
+		if err != nil {
+			return nil, emperror.With(err, RecommenderErrorTag, "vms")
+		}
+		explanation = append(explanation, rejected...)
+	}
+	return explanation, nil
+}
+
+// setCheapestZones sets the availability zone with the cheapest spot price on the spot node pools
+func setCheapestZones(zones []string, nodePools []NodePool) {
+	for i := range nodePools {
+		if nodePools[i].VmClass == Spot {
+			nodePools[i].CheapestZone = nodePools[i].VmType.CheapestZone(zones)
+		}
+	}
+}
+
+// removeZonePrices removes the per zone spot prices from the node pools to keep the response lean
+func removeZonePrices(nodePools []NodePool) {
+	for i := range nodePools {
+		nodePools[i].VmType.ZonePrices = nil
+	}
+}
+
+// setMonthlyCosts sets the estimated monthly costs on the node pools
+func (e *Engine) setMonthlyCosts(nodePools []NodePool) {
+	for i := range nodePools {
+		nodePools[i].MonthlyCost = monthlyCost(nodePools[i].PoolPrice(), e.config.HoursPerMonth)
+	}
+}
+
+// setPreferredZones sets the availability zone chosen by the zone preferences on the node pools
+func setPreferredZones(zones []string, preferZones []string, nodePools []NodePool) {
+	for i := range nodePools {
+		nodePools[i].Zone = nodePools[i].PreferredZone(zones, preferZones)
+	}
+}
+
 func (e *Engine) recommendMaster(provider, service string, req ClusterRecommendationReq, allProducts []VirtualMachine, layoutDesc []NodePoolDesc) (*NodePool, error) {
 	if layoutDesc != nil {
 		e.log.Debug("there is an existing layout, does not require a master recommendation")
@@ -183,6 +643,10 @@ func (e *Engine) getCheapestNodePoolSet(provider string, req ClusterRecommendati
 
 	attributes := []string{Cpu, Memory}
 	nodePools := make(map[string][]NodePool, 2)
+	// set if there are not enough instance types to spread the spot nodes across
+	var notEnoughTypesErr error
+	// set if the node pools don't fit within the overprovisioning cap
+	var overprovisionErr error
 
 	for _, attr := range attributes {
 		vmsInRange, err := e.vmSelector.FindVmsWithAttrValues(attr, req, layoutDesc, allProducts)
@@ -207,16 +671,49 @@ func (e *Engine) getCheapestNodePoolSet(provider string, req ClusterRecommendati
 			return nil, emperror.Wrap(err, "failed to recommend virtual machines")
 		}
 
+		if e.config.MaxCandidates > 0 && layout == nil {
+			maxCandidates := e.config.MaxCandidates
+			if maxCandidates < req.MinInstanceTypes {
+				maxCandidates = req.MinInstanceTypes
+			}
+			e.log.Debug("capping the candidate vms", map[string]interface{}{"attribute": attr,
+				"odVmsCount": len(odVms), "spotVmsCount": len(spotVms), "maxCandidates": maxCandidates})
+			odVms = capCandidates(attr, odVms, maxCandidates, VirtualMachine.RegularPrice)
+			spotVms = capCandidates(attr, spotVms, maxCandidates, VirtualMachine.SpotPrice)
+		}
+
 		if (len(odVms) == 0 && req.OnDemandPct > 0) || (len(spotVms) == 0 && req.OnDemandPct < 100) {
 			e.log.Debug("no vms with the requested resources found", map[string]interface{}{"attribute": attr})
 			// skip the nodepool creation, go to the next attr
 			continue
 		}
+
+		if req.OnDemandPct < 100 && len(spotVms) < req.MinInstanceTypes {
+			notEnoughTypesErr = errors.Errorf("only %d instance types qualify for the spot node pools, at least %d requested", len(spotVms), req.MinInstanceTypes)
+			e.log.Debug(notEnoughTypesErr.Error(), map[string]interface{}{"attribute": attr})
+			continue
+		}
 		e.log.Debug("recommended vms", map[string]interface{}{"attribute": attr,
 			"odVmsCount": len(odVms), "odVmsValues": odVms, "spotVmsCount": len(spotVms), "spotVmsValues": spotVms})
 
 		nps := e.nodePoolSelector.RecommendNodePools(attr, req, layout, odVms, spotVms)
 
+		fits := true
+		for req.MaxOverprovisionPct > 0 && layout == nil && overprovisioned(attr, req, nps) {
+			// smaller instance types fit tighter
+			odVms, spotVms = withoutLargest(attr, odVms, spotVms)
+			if (len(odVms) == 0 && req.OnDemandPct > 0) || (len(spotVms) == 0 && req.OnDemandPct < 100) {
+				fits = false
+				break
+			}
+			nps = e.nodePoolSelector.RecommendNodePools(attr, req, layout, odVms, spotVms)
+		}
+		if !fits {
+			overprovisionErr = errors.Errorf("no node pools fit within the %v%% overprovisioning cap", req.MaxOverprovisionPct)
+			e.log.Debug(overprovisionErr.Error(), map[string]interface{}{"attribute": attr})
+			continue
+		}
+
 		e.log.Debug(fmt.Sprintf("recommended node pools for [%s]: count:[%d] , values: [%#v]", attr, len(nps), nps))
 
 		nodePools[attr] = nps
@@ -224,6 +721,12 @@ func (e *Engine) getCheapestNodePoolSet(provider string, req ClusterRecommendati
 
 	if len(nodePools) == 0 {
 		e.log.Debug(fmt.Sprintf("could not recommend node pools for request: %v", req))
+		if notEnoughTypesErr != nil {
+			return nil, emperror.With(notEnoughTypesErr, RecommenderErrorTag)
+		}
+		if overprovisionErr != nil {
+			return nil, emperror.With(overprovisionErr, RecommenderErrorTag)
+		}
 		return nil, emperror.With(errors.New("could not recommend cluster with the requested resources"), RecommenderErrorTag)
 	}
 
@@ -253,6 +756,8 @@ func (e *Engine) RecommendClusterScaleOut(provider string, service string, regio
 		SumCpu:        req.DesiredCpu,
 		SumMem:        req.DesiredMem,
 		SumGpu:        req.DesiredGpu,
+		// the existing layout may consist of gpu instance types
+		AllowGpu: true,
 	}
 
 	return e.RecommendCluster(provider, service, region, clReq, req.ActualLayout)
@@ -278,7 +783,12 @@ func (e *Engine) RecommendMultiCluster(req MultiClusterRecommendationReq) (map[s
 				}
 			}
 
-			limitedResponses := e.getLimitedResponses(responses, req.RespPerService)
+			var limitedResponses []*ClusterRecommendationResp
+			if req.ClusterRecommendationReq.Objective == Carbon {
+				limitedResponses = limitByCarbonIntensity(responses, req.RespPerService)
+			} else {
+				limitedResponses = e.getLimitedResponses(responses, req.RespPerService)
+			}
 			key := strings.Join([]string{strings.ToLower(provider.Provider), strings.ToUpper(service)}, "")
 			respPerService[key] = limitedResponses
 		}
@@ -292,7 +802,7 @@ func (e *Engine) RecommendMultiCluster(req MultiClusterRecommendationReq) (map[s
 
 func (e *Engine) getRegions(provider, service string, req MultiClusterRecommendationReq) ([]string, error) {
 	var regions []string
-	continents, err := e.ciSource.GetRegions(provider, service)
+	continents, err := Regions(e.ctx, e.ciSource, provider, service)
 	if err != nil {
 		return nil, err
 	}
@@ -380,6 +890,39 @@ func findResponseSum(zones []string, nodePoolSet []NodePool) ClusterRecommendati
 	}
 }
 
+// overprovisioned checks whether the node pools exceed the requested value of the attribute by more than the cap
+func overprovisioned(attr string, req ClusterRecommendationReq, nodePools []NodePool) bool {
+	requested := req.SumCpu
+	if attr == Memory {
+		requested = req.SumMem
+	}
+
+	var recommended float64
+	for _, np := range nodePools {
+		recommended += np.GetSum(attr)
+	}
+	return recommended > requested*(1+req.MaxOverprovisionPct/100)
+}
+
+// withoutLargest removes the vms with the largest value of the attribute from the regular and spot candidates
+func withoutLargest(attr string, odVms, spotVms []VirtualMachine) ([]VirtualMachine, []VirtualMachine) {
+	var largest float64
+	for _, vm := range append(append([]VirtualMachine(nil), odVms...), spotVms...) {
+		largest = math.Max(largest, vm.GetAttrValue(attr))
+	}
+
+	smaller := func(vms []VirtualMachine) []VirtualMachine {
+		var kept []VirtualMachine
+		for _, vm := range vms {
+			if vm.GetAttrValue(attr) < largest {
+				kept = append(kept, vm)
+			}
+		}
+		return kept
+	}
+	return smaller(odVms), smaller(spotVms)
+}
+
 // findCheapestNodePoolSet looks up the "cheapest" node pool set from the provided map
 func (e *Engine) findCheapestNodePoolSet(nodePoolSets map[string][]NodePool) []NodePool {
 	e.log.Info("finding cheapest pool set...")
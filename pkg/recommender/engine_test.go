@@ -15,10 +15,14 @@
 package recommender
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/goph/emperror"
 	"github.com/goph/logur"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,6 +38,7 @@ func (p *dummyProducts) GetProductDetails(provider string, service string, regio
 			Mem:           42,
 			OnDemandPrice: 3,
 			AvgPrice:      0.8,
+			Zones:         []string{"dummyZone1", "dummyZone2", "dummyZone3"},
 		},
 	}, nil
 }
@@ -80,6 +85,23 @@ func (v *dummyVms) FindVmsWithAttrValues(attr string, req ClusterRecommendationR
 	return nil, nil
 }
 
+func (v *dummyVms) ExplainVms(provider string, attr string, req ClusterRecommendationReq, allProducts []VirtualMachine) ([]RejectedVm, error) {
+	return []RejectedVm{
+		{
+			Type:      "dummy",
+			Attribute: attr,
+			Reasons:   []string{"in the excludes list"},
+		},
+	}, nil
+}
+
+func (v *dummyVms) Capabilities() []ProviderCapabilities {
+	return []ProviderCapabilities{
+		{Provider: "dummyProvider", Spot: true, Zones: true, WorkloadTypes: []string{GeneralWorkload}},
+		{Provider: "noSpotProvider", Gpu: true, WorkloadTypes: []string{}},
+	}
+}
+
 type dummyNodePools struct {
 	// test case id to drive the behaviour
 	TcId string
@@ -164,12 +186,65 @@ func TestEngine_RecommendCluster(t *testing.T) {
 				assert.Nil(t, err, "the error should be nil")
 				assert.Equal(t, float64(42), resp.Accuracy.RecMem)
 				assert.Equal(t, float64(16), resp.Accuracy.RecCpu)
+				assert.Nil(t, resp.Explanation, "the explanation should not be present")
+			},
+		},
+		{
+			name: "unsatisfiable node bounds",
+			vms:  &dummyVms{},
+			np:   &dummyNodePools{},
+			request: ClusterRecommendationReq{
+				MinNodes: 1,
+				MaxNodes: 3,
+				MaxVcpu:  8,
+				SumMem:   32,
+				SumCpu:   32,
+			},
+			ciSource: &dummyProducts{},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.NotNil(t, err, "the error should not be nil")
+				assert.Nil(t, resp)
+			},
+		},
+		{
+			name: "not enough instance types for the spot pools",
+			vms:  &dummyVms{},
+			np:   &dummyNodePools{},
+			request: ClusterRecommendationReq{
+				MinNodes:         1,
+				MaxNodes:         1,
+				SumMem:           32,
+				SumCpu:           16,
+				MinInstanceTypes: 5,
+			},
+			ciSource: &dummyProducts{},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, "only 4 instance types qualify for the spot node pools, at least 5 requested")
+				assert.Nil(t, resp)
+			},
+		},
+		{
+			name: "cluster recommendation with explanation",
+			vms:  &dummyVms{},
+			np:   &dummyNodePools{},
+			request: ClusterRecommendationReq{
+				MinNodes: 1,
+				MaxNodes: 1,
+				SumMem:   32,
+				SumCpu:   16,
+				Explain:  true,
+			},
+			ciSource: &dummyProducts{},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, 2, len(resp.Explanation))
+				assert.Equal(t, "dummy", resp.Explanation[0].Type)
 			},
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			engine := NewEngine(logur.NewTestLogger(), test.ciSource, test.vms, test.np)
+			engine := NewEngine(logur.NewTestLogger(), test.ciSource, test.vms, test.np, nil, EngineConfig{})
 
 			test.check(engine.RecommendCluster("dummyProvider", "dummyService", "dummyRegion", test.request, nil))
 		})
@@ -238,8 +313,959 @@ func TestEngine_findCheapestNodePoolSet(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			engine := NewEngine(logur.NewTestLogger(), nil, test.vms, test.np)
+			engine := NewEngine(logur.NewTestLogger(), nil, test.vms, test.np, nil, EngineConfig{})
 			test.check(engine.findCheapestNodePoolSet(test.nodePools))
 		})
 	}
 }
+
+type dummyInterruptions struct {
+	err   error
+	calls int
+}
+
+func (i *dummyInterruptions) GetInterruptionRatings(provider, region string) (map[string]int, error) {
+	i.calls++
+	if i.err != nil {
+		return nil, i.err
+	}
+	return map[string]int{"m5.xlarge": 1, "c5.xlarge": 3}, nil
+}
+
+func TestEngine_setInterruptionRatings(t *testing.T) {
+	tests := []struct {
+		name   string
+		source InterruptionSource
+		check  func(vms []VirtualMachine)
+	}{
+		{
+			name:   "ratings set on the vms",
+			source: &dummyInterruptions{},
+			check: func(vms []VirtualMachine) {
+				assert.Equal(t, 1, vms[0].InterruptionRating)
+				assert.Equal(t, 3, vms[1].InterruptionRating)
+				assert.Equal(t, 0, vms[2].InterruptionRating, "unknown vms should not be rated")
+			},
+		},
+		{
+			name:   "unavailable ratings",
+			source: &dummyInterruptions{err: errors.New("spot advisor data unreachable")},
+			check: func(vms []VirtualMachine) {
+				for _, vm := range vms {
+					assert.Equal(t, 0, vm.InterruptionRating)
+				}
+			},
+		},
+		{
+			name:   "no interruption source",
+			source: nil,
+			check: func(vms []VirtualMachine) {
+				for _, vm := range vms {
+					assert.Equal(t, 0, vm.InterruptionRating)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), nil, nil, nil, test.source, EngineConfig{})
+			vms := []VirtualMachine{{Type: "m5.xlarge"}, {Type: "c5.xlarge"}, {Type: "r5.xlarge"}}
+
+			engine.setInterruptionRatings("amazon", "eu-west-1", vms)
+			test.check(vms)
+		})
+	}
+}
+
+func TestEngine_RecommendClusterOnDemandOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		request ClusterRecommendationReq
+		check   func(resp *ClusterRecommendationResp, err error, spotCalls int)
+	}{
+		{
+			name: "spot information is used for spot pools",
+			request: ClusterRecommendationReq{
+				MinNodes:  1,
+				MaxNodes:  1,
+				SumMem:    32,
+				SumCpu:    16,
+				Objective: Stability,
+			},
+			check: func(resp *ClusterRecommendationResp, err error, spotCalls int) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, 1, spotCalls)
+			},
+		},
+		{
+			name: "no spot information is used when on-demand only",
+			request: ClusterRecommendationReq{
+				MinNodes:     1,
+				MaxNodes:     1,
+				SumMem:       32,
+				SumCpu:       16,
+				OnDemandPct:  30,
+				OnDemandOnly: true,
+				Objective:    Stability,
+			},
+			check: func(resp *ClusterRecommendationResp, err error, spotCalls int) {
+				// the dummy vm recommender doesn't provide on-demand vms, only the spot calls are checked
+				assert.Equal(t, 0, spotCalls)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			interruptions := &dummyInterruptions{}
+			engine := NewEngine(logur.NewTestLogger(), &dummyProducts{}, &dummyVms{}, &dummyNodePools{}, interruptions, EngineConfig{})
+
+			resp, err := engine.RecommendCluster("dummyProvider", "dummyService", "dummyRegion", test.request, nil)
+			test.check(resp, err, interruptions.calls)
+		})
+	}
+}
+
+func TestEngine_RecommendClusterCurrency(t *testing.T) {
+	request := ClusterRecommendationReq{
+		MinNodes: 1,
+		MaxNodes: 1,
+		SumMem:   32,
+		SumCpu:   16,
+	}
+	tests := []struct {
+		name     string
+		currency string
+		check    func(resp *ClusterRecommendationResp, usdResp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:     "prices in USD by default",
+			currency: "",
+			check: func(resp *ClusterRecommendationResp, usdResp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, DefaultCurrency, resp.Currency)
+				assert.Equal(t, usdResp.NodePools, resp.NodePools)
+			},
+		},
+		{
+			name:     "prices converted by the configured rate",
+			currency: "eur",
+			check: func(resp *ClusterRecommendationResp, usdResp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "EUR", resp.Currency)
+				for i, np := range resp.NodePools {
+					assert.Equal(t, usdResp.NodePools[i].VmType.OnDemandPrice*0.5, np.VmType.OnDemandPrice)
+					assert.Equal(t, usdResp.NodePools[i].VmType.AvgPrice*0.5, np.VmType.AvgPrice)
+					assert.Equal(t, np.VmType.OnDemandPrice/np.VmType.Cpus, np.VmType.OnDemandPricePerVcpu)
+				}
+				assert.Equal(t, usdResp.Accuracy.RecTotalPrice*0.5, resp.Accuracy.RecTotalPrice)
+			},
+		},
+		{
+			name:     "no rate configured for the currency",
+			currency: "GBP",
+			check: func(resp *ClusterRecommendationResp, usdResp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, "no conversion rate configured for currency GBP")
+				assert.Nil(t, resp, "the response should be nil")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), &dummyProducts{}, &dummyVms{}, &dummyNodePools{}, nil, EngineConfig{CurrencyRates: map[string]float64{"EUR": 0.5}})
+
+			usdResp, err := engine.RecommendCluster("dummyProvider", "dummyService", "dummyRegion", request, nil)
+			assert.Nil(t, err, "the error should be nil")
+
+			req := request
+			req.Currency = test.currency
+			resp, err := engine.RecommendCluster("dummyProvider", "dummyService", "dummyRegion", req, nil)
+			test.check(resp, usdResp, err)
+		})
+	}
+}
+
+func TestEngine_RecommendClusterDefaultZones(t *testing.T) {
+	defaultZones := map[string][]string{"dummyRegion": {"dummyZone1", "dummyZone2"}}
+	tests := []struct {
+		name   string
+		region string
+		zones  []string
+		check  func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:   "the default zones of the region are used if the request has no zones",
+			region: "dummyRegion",
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"dummyZone1", "dummyZone2"}, resp.Zones)
+			},
+		},
+		{
+			name:   "the zones of the request override the default ones",
+			region: "dummyRegion",
+			zones:  []string{"dummyZone3"},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"dummyZone3"}, resp.Zones)
+			},
+		},
+		{
+			name:   "all zones are used in regions without default zones",
+			region: "otherRegion",
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Empty(t, resp.Zones)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), &dummyProducts{}, &dummyVms{}, &dummyNodePools{}, nil, EngineConfig{DefaultZones: defaultZones})
+
+			req := ClusterRecommendationReq{MinNodes: 1, MaxNodes: 1, SumMem: 32, SumCpu: 16, Zones: test.zones}
+			test.check(engine.RecommendCluster("dummyProvider", "dummyService", test.region, req, nil))
+		})
+	}
+}
+
+func TestEngine_Capabilities(t *testing.T) {
+	engine := NewEngine(logur.NewTestLogger(), &dummyProducts{}, &dummyVms{}, &dummyNodePools{}, nil, EngineConfig{})
+
+	capabilities := engine.Capabilities()
+	assert.Equal(t, []ProviderCapabilities{
+		{Provider: "dummyProvider", Spot: true, Zones: true, WorkloadTypes: []string{GeneralWorkload}},
+		{Provider: "noSpotProvider", Gpu: true, WorkloadTypes: []string{}},
+	}, capabilities)
+}
+
+func TestEngine_setMonthlyCosts(t *testing.T) {
+	tests := []struct {
+		name          string
+		hoursPerMonth float64
+		nodePools     []NodePool
+		check         func(nps []NodePool)
+	}{
+		{
+			name:          "monthly costs by the configured hours",
+			hoursPerMonth: 720,
+			nodePools: []NodePool{
+				{VmType: VirtualMachine{OnDemandPrice: 0.2, AvgPrice: 0.1}, SumNodes: 3, VmClass: Regular},
+				{VmType: VirtualMachine{OnDemandPrice: 0.2, AvgPrice: 0.1}, SumNodes: 2, VmClass: Spot},
+			},
+			check: func(nps []NodePool) {
+				assert.InDelta(t, 0.2*720*3, nps[0].MonthlyCost, 1e-9)
+				assert.InDelta(t, 0.1*720*2, nps[1].MonthlyCost, 1e-9)
+			},
+		},
+		{
+			name: "monthly costs by the default hours",
+			nodePools: []NodePool{
+				{VmType: VirtualMachine{OnDemandPrice: 0.2}, SumNodes: 1, VmClass: Regular},
+			},
+			check: func(nps []NodePool) {
+				assert.InDelta(t, 0.2*DefaultHoursPerMonth, nps[0].MonthlyCost, 1e-9)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), nil, nil, nil, nil, EngineConfig{HoursPerMonth: test.hoursPerMonth})
+
+			engine.setMonthlyCosts(test.nodePools)
+			test.check(test.nodePools)
+		})
+	}
+}
+
+func TestEngine_ValidateClusterRecommendationReq(t *testing.T) {
+	tests := []struct {
+		name    string
+		request ClusterRecommendationReq
+		check   func(err error)
+	}{
+		{
+			name: "valid request",
+			request: ClusterRecommendationReq{
+				SumCpu:   16,
+				SumMem:   32,
+				MinNodes: 1,
+				MaxNodes: 4,
+				MaxVcpu:  8,
+				Currency: "EUR",
+			},
+			check: func(err error) {
+				assert.Nil(t, err, "the error should be nil")
+			},
+		},
+		{
+			name: "node bounds can't be satisfied",
+			request: ClusterRecommendationReq{
+				SumCpu:   64,
+				SumMem:   32,
+				MinNodes: 1,
+				MaxNodes: 4,
+				MaxVcpu:  8,
+			},
+			check: func(err error) {
+				assert.EqualError(t, err, "4 nodes with at most 8 cpus can't provide the requested 64 cpus")
+			},
+		},
+		{
+			name: "no rate configured for the currency",
+			request: ClusterRecommendationReq{
+				SumCpu:   16,
+				SumMem:   32,
+				MinNodes: 1,
+				MaxNodes: 4,
+				Currency: "GBP",
+			},
+			check: func(err error) {
+				assert.EqualError(t, err, "no conversion rate configured for currency GBP")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), nil, nil, nil, nil, EngineConfig{CurrencyRates: map[string]float64{"EUR": 0.9}})
+
+			test.check(engine.ValidateClusterRecommendationReq(test.request))
+		})
+	}
+}
+
+type zonedProducts struct {
+	dummyProducts
+}
+
+func (p *zonedProducts) GetProductDetails(provider string, service string, region string) ([]VirtualMachine, error) {
+	return []VirtualMachine{
+		{
+			Cpus:          16,
+			Mem:           42,
+			OnDemandPrice: 3,
+			AvgPrice:      0.8,
+			Zones:         []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"},
+			ZonePrices:    map[string]float64{"eu-west-1a": 0.7, "eu-west-1b": 0.9},
+		},
+	}, nil
+}
+
+func TestEngine_RecommendClusterWarnings(t *testing.T) {
+	tests := []struct {
+		name    string
+		request ClusterRecommendationReq
+		check   func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name: "zone without spot prices reported",
+			request: ClusterRecommendationReq{
+				MinNodes: 1,
+				MaxNodes: 1,
+				SumMem:   32,
+				SumCpu:   16,
+				Zones:    []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"},
+			},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"no spot prices available in zone eu-west-1c, the spot prices of the other zones are used"}, resp.Warnings)
+				assert.NotEmpty(t, resp.NodePools)
+			},
+		},
+		{
+			name: "no warnings",
+			request: ClusterRecommendationReq{
+				MinNodes: 1,
+				MaxNodes: 1,
+				SumMem:   32,
+				SumCpu:   16,
+				Zones:    []string{"eu-west-1a", "eu-west-1b"},
+			},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Empty(t, resp.Warnings)
+			},
+		},
+		{
+			name: "interruption ratings not available",
+			request: ClusterRecommendationReq{
+				MinNodes:  1,
+				MaxNodes:  1,
+				SumMem:    32,
+				SumCpu:    16,
+				Objective: Stability,
+			},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"spot interruption ratings are not available, the spot instance types are ranked by price"}, resp.Warnings)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			interruptions := &dummyInterruptions{err: errors.New("spot advisor data unreachable")}
+			engine := NewEngine(logur.NewTestLogger(), &zonedProducts{}, &dummyVms{}, &dummyNodePools{}, interruptions, EngineConfig{})
+
+			test.check(engine.RecommendCluster("dummyProvider", "dummyService", "dummyRegion", test.request, nil))
+		})
+	}
+}
+
+// fixedProducts returns a copy of the same products for every region
+type fixedProducts []VirtualMachine
+
+func (p fixedProducts) GetProductDetails(provider string, service string, region string) ([]VirtualMachine, error) {
+	return append([]VirtualMachine(nil), p...), nil
+}
+
+func (p fixedProducts) GetRegions(provider, service string) ([]*models.Continent, error) {
+	return nil, nil
+}
+
+// passThroughVms recommends all the vms for regular and spot node pools
+type passThroughVms struct {
+	dummyVms
+}
+
+func (v *passThroughVms) FindVmsWithAttrValues(attr string, req ClusterRecommendationReq, layoutDesc []NodePoolDesc, allProducts []VirtualMachine) ([]VirtualMachine, error) {
+	return allProducts, nil
+}
+
+func (v *passThroughVms) RecommendVms(provider string, vms []VirtualMachine, attr string, req ClusterRecommendationReq, layout []NodePool) ([]VirtualMachine, []VirtualMachine, error) {
+	return vms, vms, nil
+}
+
+// cheapestOnDemandPool recommends a single regular node pool of the vm with the lowest on-demand price per attribute
+type cheapestOnDemandPool struct{}
+
+func (nps *cheapestOnDemandPool) RecommendNodePools(attr string, req ClusterRecommendationReq, layout []NodePool, odVms []VirtualMachine, spotVms []VirtualMachine) []NodePool {
+	cheapest := odVms[0]
+	for _, vm := range odVms {
+		if vm.OnDemandPrice/vm.GetAttrValue(attr) < cheapest.OnDemandPrice/cheapest.GetAttrValue(attr) {
+			cheapest = vm
+		}
+	}
+	return []NodePool{{VmType: cheapest, SumNodes: int(math.Ceil(req.SumCpu / cheapest.Cpus)), VmClass: Regular, Role: Worker}}
+}
+
+func TestEngine_RecommendClusterOnDemandDiscounts(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192},
+		{Type: "c5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.17},
+		{Type: "m5a.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.172},
+	}
+	tests := []struct {
+		name      string
+		discounts map[string]float64
+		check     func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name: "list prices by default",
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "c5.xlarge", resp.NodePools[0].VmType.Type)
+				assert.Equal(t, 0.17, resp.NodePools[0].VmType.OnDemandPrice)
+			},
+		},
+		{
+			name:      "the discounted family wins over a cheaper one",
+			discounts: map[string]float64{"m5": 40},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m5.xlarge", resp.NodePools[0].VmType.Type)
+				assert.InDelta(t, 0.1152, resp.NodePools[0].VmType.OnDemandPrice, 1e-9)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &cheapestOnDemandPool{}, nil, EngineConfig{})
+
+			req := ClusterRecommendationReq{MinNodes: 2, MaxNodes: 2, SumCpu: 8, SumMem: 32, OnDemandPct: 100, OnDemandDiscounts: test.discounts}
+			test.check(engine.RecommendCluster("amazon", "compute", "eu-west-1", req, nil))
+		})
+	}
+}
+
+func TestEngine_RecommendClusterStalePrices(t *testing.T) {
+	now := time.Date(2019, 6, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		updated time.Time
+		maxAge  time.Duration
+		check   func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:    "prices older than the maximum age reported",
+			updated: now.Add(-72 * time.Hour),
+			maxAge:  24 * time.Hour,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"the prices were last updated 72h0m0s ago, the recommendation may be based on stale prices"}, resp.Warnings)
+				assert.NotEmpty(t, resp.NodePools)
+			},
+		},
+		{
+			name:    "fresh prices",
+			updated: now.Add(-time.Hour),
+			maxAge:  24 * time.Hour,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Empty(t, resp.Warnings)
+			},
+		},
+		{
+			name:   "unknown update time",
+			maxAge: 24 * time.Hour,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Empty(t, resp.Warnings)
+			},
+		},
+		{
+			name:    "staleness check disabled",
+			updated: now.Add(-72 * time.Hour),
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Empty(t, resp.Warnings)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			products := fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, PricesUpdated: test.updated}}
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &cheapestOnDemandPool{}, nil, EngineConfig{MaxPriceAge: test.maxAge})
+			engine.now = func() time.Time { return now }
+
+			req := ClusterRecommendationReq{MinNodes: 2, MaxNodes: 2, SumCpu: 8, SumMem: 32, OnDemandPct: 100}
+			test.check(engine.RecommendCluster("amazon", "compute", "eu-west-1", req, nil))
+		})
+	}
+}
+
+func TestEngine_RecommendClusterMaxOverprovision(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.4xlarge", Cpus: 16, Mem: 64, OnDemandPrice: 0.7},
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192},
+	}
+	tests := []struct {
+		name   string
+		sumCpu float64
+		cap    float64
+		check  func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:   "loose cap allows the big instance type",
+			sumCpu: 20,
+			cap:    100,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m5.4xlarge", resp.NodePools[0].VmType.Type)
+				assert.Equal(t, 32.0, resp.Accuracy.RecCpu)
+			},
+		},
+		{
+			name:   "tight cap forces smaller instance types",
+			sumCpu: 20,
+			cap:    20,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m5.xlarge", resp.NodePools[0].VmType.Type)
+				assert.Equal(t, 20.0, resp.Accuracy.RecCpu)
+			},
+		},
+		{
+			name:   "nothing fits within the cap",
+			sumCpu: 18,
+			cap:    10,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, "no node pools fit within the 10% overprovisioning cap")
+				assert.Contains(t, emperror.Context(err), RecommenderErrorTag)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &cheapestOnDemandPool{}, nil, EngineConfig{})
+
+			req := ClusterRecommendationReq{MinNodes: 1, MaxNodes: 8, SumCpu: test.sumCpu, SumMem: 4 * test.sumCpu, OnDemandPct: 100, MaxOverprovisionPct: test.cap}
+			test.check(engine.RecommendCluster("amazon", "compute", "eu-west-1", req, nil))
+		})
+	}
+}
+
+func TestEngine_RecommendClusterMemPerCpu(t *testing.T) {
+	products := fixedProducts{
+		{Type: "c5.xlarge", Cpus: 4, Mem: 8, OnDemandPrice: 0.17},
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192},
+	}
+	tests := []struct {
+		name   string
+		sumMem float64
+		check  func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:   "memory overprovisioned within the tolerance",
+			sumMem: 64,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.NotNil(t, resp)
+			},
+		},
+		{
+			name:   "impossible memory per cpu",
+			sumMem: 1,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, "the requested 1 GB memory for 64 cpus is 0.0156 GB per cpu, but the instance types in the region have at least 2 GB per cpu - request at least 128 GB memory")
+				assert.Contains(t, emperror.Context(err), RecommenderErrorTag)
+				assert.Nil(t, resp)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &cheapestOnDemandPool{}, nil, EngineConfig{})
+
+			req := ClusterRecommendationReq{MinNodes: 1, MaxNodes: 16, SumCpu: 64, SumMem: test.sumMem, OnDemandPct: 100}
+			test.check(engine.RecommendCluster("amazon", "compute", "eu-west-1", req, nil))
+		})
+	}
+}
+
+func TestEngine_RecommendClusterZonesNotInRegion(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.07, Zones: []string{"us-east-1a", "us-east-1b"}},
+		{Type: "c5.xlarge", Cpus: 4, Mem: 8, OnDemandPrice: 0.17, AvgPrice: 0.06, Zones: []string{"us-east-1c"}},
+	}
+	tests := []struct {
+		name  string
+		zones []string
+		check func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:  "zones of the region",
+			zones: []string{"us-east-1a", "us-east-1c"},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.NotNil(t, resp)
+			},
+		},
+		{
+			name:  "zone of another region",
+			zones: []string{"us-east-1a", "us-west-2a"},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, "the zones us-west-2a are not availability zones of region us-east-1")
+				assert.Contains(t, emperror.Context(err), RecommenderErrorTag)
+				assert.Nil(t, resp)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &cheapestOnDemandPool{}, nil, EngineConfig{})
+
+			req := ClusterRecommendationReq{MinNodes: 2, MaxNodes: 2, SumCpu: 8, SumMem: 32, OnDemandPct: 100, Zones: test.zones}
+			test.check(engine.RecommendCluster("amazon", "compute", "us-east-1", req, nil))
+		})
+	}
+}
+
+func TestEngine_RecommendClusterNoZones(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		products fixedProducts
+		check    func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:     "no zones available in the region",
+			provider: "dummyProvider",
+			products: fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.07}},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Equal(t, ErrNoZones, errors.Cause(err))
+				assert.EqualError(t, err, "region dummyRegion: no availability zones available, the region may be new or restricted")
+				assert.Contains(t, emperror.Context(err), UnprocessableErrTag)
+				assert.Nil(t, resp)
+			},
+		},
+		{
+			name:     "zones available in the region",
+			provider: "dummyProvider",
+			products: fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.07, Zones: []string{"dummyZone"}}},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.NotNil(t, resp)
+			},
+		},
+		{
+			name:     "provider without zones",
+			provider: "noSpotProvider",
+			products: fixedProducts{{Type: "VM.Standard2.1", Cpus: 2, Mem: 15, OnDemandPrice: 0.06, AvgPrice: 0.06}},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.NotNil(t, resp)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), test.products, &passThroughVms{}, &firstSpotPool{}, nil, EngineConfig{})
+
+			req := ClusterRecommendationReq{MinNodes: 2, MaxNodes: 2, SumCpu: 8, SumMem: 32}
+			test.check(engine.RecommendCluster(test.provider, "dummyService", "dummyRegion", req, nil))
+		})
+	}
+}
+
+// firstSpotPool recommends a single spot node pool of the first spot vm
+type firstSpotPool struct{}
+
+func (nps *firstSpotPool) RecommendNodePools(attr string, req ClusterRecommendationReq, layout []NodePool, odVms []VirtualMachine, spotVms []VirtualMachine) []NodePool {
+	return []NodePool{{VmType: spotVms[0], SumNodes: int(math.Ceil(req.SumCpu / spotVms[0].Cpus)), VmClass: Spot, Role: Worker}}
+}
+
+func TestEngine_RecommendClusterRawPrices(t *testing.T) {
+	zonePrices := map[string]float64{"eu-west-1a": 0.07, "eu-west-1b": 0.08, "eu-west-1c": 0.12}
+	products := fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.09, ZonePrices: zonePrices}}
+	tests := []struct {
+		name      string
+		rawPrices bool
+		check     func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name: "no raw prices by default",
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Nil(t, resp.NodePools[0].VmType.ZonePrices)
+				assert.Equal(t, "eu-west-1a", resp.NodePools[0].CheapestZone)
+			},
+		},
+		{
+			name:      "raw prices requested",
+			rawPrices: true,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				vm := resp.NodePools[0].VmType
+				assert.Equal(t, zonePrices, vm.ZonePrices)
+
+				var sum float64
+				for _, price := range vm.ZonePrices {
+					sum += price
+				}
+				assert.InDelta(t, vm.AvgPrice, sum/float64(len(vm.ZonePrices)), 1e-9)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &firstSpotPool{}, nil, EngineConfig{})
+
+			req := ClusterRecommendationReq{MinNodes: 2, MaxNodes: 2, SumCpu: 8, SumMem: 32, IncludeRawPrices: test.rawPrices}
+			test.check(engine.RecommendCluster("amazon", "compute", "eu-west-1", req, nil))
+		})
+	}
+}
+
+// scoringPool recommends a single spot node pool of the best scored spot vm
+type scoringPool struct {
+	scorer Scorer
+}
+
+func (nps *scoringPool) RecommendNodePools(attr string, req ClusterRecommendationReq, layout []NodePool, odVms []VirtualMachine, spotVms []VirtualMachine) []NodePool {
+	best := spotVms[0]
+	for _, vm := range spotVms[1:] {
+		if nps.scorer != nil && nps.scorer.Score(vm, req) < nps.scorer.Score(best, req) {
+			best = vm
+		}
+	}
+	return []NodePool{{VmType: best, SumNodes: int(math.Ceil(req.SumCpu / best.Cpus)), VmClass: Spot, Role: Worker}}
+}
+
+func (nps *scoringPool) WithScorer(scorer Scorer) NodePoolRecommender {
+	return &scoringPool{scorer: scorer}
+}
+
+// pricierScorer prefers the most expensive instance types per cpu
+type pricierScorer struct{}
+
+func (pricierScorer) Score(vm VirtualMachine, req ClusterRecommendationReq) float64 {
+	return -PriceScorer{}.Score(vm, req)
+}
+
+func TestEngine_RecommendClusterScorer(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.09},
+		{Type: "m4.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.2, AvgPrice: 0.05},
+		{Type: "m3.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.266, AvgPrice: 0.07},
+	}
+	tests := []struct {
+		name   string
+		scorer Scorer
+		check  func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:   "the price scorer prefers the cheapest spot type",
+			scorer: PriceScorer{},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m4.xlarge", resp.NodePools[0].VmType.Type)
+			},
+		},
+		{
+			name:   "a custom scorer replaces the price ranking",
+			scorer: pricierScorer{},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m5.xlarge", resp.NodePools[0].VmType.Type)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &scoringPool{}, nil, EngineConfig{Scorer: test.scorer})
+			test.check(engine.RecommendCluster("dummy", "dummy", "dummy", ClusterRecommendationReq{SumCpu: 4, SumMem: 16, MinNodes: 1, MaxNodes: 1, Zones: []string{"dummyZone1"}}, nil))
+		})
+	}
+}
+
+// recordingPool records the candidates it recommends the node pools from, and recommends a single spot node pool of the first one
+type recordingPool struct {
+	odVms, spotVms []VirtualMachine
+}
+
+func (nps *recordingPool) RecommendNodePools(attr string, req ClusterRecommendationReq, layout []NodePool, odVms []VirtualMachine, spotVms []VirtualMachine) []NodePool {
+	nps.odVms, nps.spotVms = odVms, spotVms
+	return []NodePool{{VmType: spotVms[0], SumNodes: int(math.Ceil(req.SumCpu / spotVms[0].Cpus)), VmClass: Spot, Role: Worker}}
+}
+
+func TestEngine_RecommendClusterMaxCandidates(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.09},
+		{Type: "m4.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.2, AvgPrice: 0.05},
+		{Type: "m3.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.266, AvgPrice: 0.07},
+		{Type: "m5.2xlarge", Cpus: 8, Mem: 32, OnDemandPrice: 0.384, AvgPrice: 0.2},
+	}
+	tests := []struct {
+		name          string
+		maxCandidates int
+		req           ClusterRecommendationReq
+		check         func(pool *recordingPool)
+	}{
+		{
+			name: "all candidates without a cap",
+			req:  ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2},
+			check: func(pool *recordingPool) {
+				assert.Len(t, pool.odVms, 4)
+				assert.Len(t, pool.spotVms, 4)
+			},
+		},
+		{
+			name:          "the cheapest candidates by their own price",
+			maxCandidates: 2,
+			req:           ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2},
+			check: func(pool *recordingPool) {
+				assert.Equal(t, []string{"m5.xlarge", "m5.2xlarge"}, []string{pool.odVms[0].Type, pool.odVms[1].Type})
+				assert.Equal(t, []string{"m4.xlarge", "m3.xlarge"}, []string{pool.spotVms[0].Type, pool.spotVms[1].Type})
+			},
+		},
+		{
+			name:          "at least the minimum number of instance types are kept",
+			maxCandidates: 2,
+			req:           ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2, MinInstanceTypes: 3},
+			check: func(pool *recordingPool) {
+				assert.Len(t, pool.spotVms, 3)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := &recordingPool{}
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, pool, nil, EngineConfig{MaxCandidates: test.maxCandidates})
+			_, err := engine.RecommendCluster("dummy", "dummy", "dummy", test.req, nil)
+			assert.Nil(t, err, "the error should be nil")
+			test.check(pool)
+		})
+	}
+}
+
+// spotPricedVms recommends all the vms for regular, and the ones with a spot price for spot node pools
+type spotPricedVms struct {
+	dummyVms
+}
+
+func (v *spotPricedVms) FindVmsWithAttrValues(attr string, req ClusterRecommendationReq, layoutDesc []NodePoolDesc, allProducts []VirtualMachine) ([]VirtualMachine, error) {
+	return allProducts, nil
+}
+
+func (v *spotPricedVms) RecommendVms(provider string, vms []VirtualMachine, attr string, req ClusterRecommendationReq, layout []NodePool) ([]VirtualMachine, []VirtualMachine, error) {
+	var spotVms []VirtualMachine
+	for _, vm := range vms {
+		if vm.AvgPrice > 0 {
+			spotVms = append(spotVms, vm)
+		}
+	}
+	return vms, spotVms, nil
+}
+
+// cheapestSpotPool recommends a single spot node pool of the vm with the lowest spot price per attribute
+type cheapestSpotPool struct{}
+
+func (nps *cheapestSpotPool) RecommendNodePools(attr string, req ClusterRecommendationReq, layout []NodePool, odVms []VirtualMachine, spotVms []VirtualMachine) []NodePool {
+	if len(spotVms) == 0 {
+		return []NodePool{{VmType: odVms[0], SumNodes: int(math.Ceil(req.SumCpu / odVms[0].Cpus)), VmClass: Regular, Role: Worker}}
+	}
+	cheapest := spotVms[0]
+	for _, vm := range spotVms {
+		if vm.AvgPrice/vm.GetAttrValue(attr) < cheapest.AvgPrice/cheapest.GetAttrValue(attr) {
+			cheapest = vm
+		}
+	}
+	return []NodePool{{VmType: cheapest, SumNodes: int(math.Ceil(req.SumCpu / cheapest.Cpus)), VmClass: Spot, Role: Worker}}
+}
+
+func TestEngine_RecommendClusterSpotFallback(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192},
+		{Type: "m4.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.4, AvgPrice: 0.3},
+	}
+	tests := []struct {
+		name       string
+		multiplier float64
+		check      func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name: "types without a spot price are left out by default",
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m4.xlarge", resp.NodePools[0].VmType.Type)
+				assert.Empty(t, resp.Warnings)
+			},
+		},
+		{
+			name:       "the fallback price with the penalty is cheaper",
+			multiplier: 1.2,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m5.xlarge", resp.NodePools[0].VmType.Type)
+				assert.Equal(t, Spot, resp.NodePools[0].VmClass)
+				assert.InDelta(t, 0.2304, resp.NodePools[0].VmType.AvgPrice, 0.0001)
+				assert.Equal(t, []string{"no spot price available for m5.xlarge, it's priced at 1.2 times its on-demand price"}, resp.Warnings)
+			},
+		},
+		{
+			name:       "the fallback price with the penalty is more expensive",
+			multiplier: 2,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, "m4.xlarge", resp.NodePools[0].VmType.Type)
+				assert.Empty(t, resp.Warnings)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &spotPricedVms{}, &cheapestSpotPool{}, nil, EngineConfig{})
+			req := ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2, SpotFallbackMultiplier: test.multiplier}
+			test.check(engine.RecommendCluster("dummy", "dummy", "dummy", req, nil))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ec2FamiliesWithoutEna holds the EC2 instance families without Elastic Network Adapter (enhanced networking) support
+// some of them support enhanced networking by the Intel 82599 VF interface only
+var ec2FamiliesWithoutEna = map[string]bool{
+	"t1": true, "t2": true, "m1": true, "m2": true, "m3": true, "m4": true,
+	"c1": true, "c3": true, "c4": true, "cc2": true, "cr1": true, "r3": true,
+	"i2": true, "d2": true, "g2": true, "hs1": true,
+}
+
+// ec2FamiliesWithoutEbsOptimization holds the EC2 instance families that are not EBS-optimized by default
+var ec2FamiliesWithoutEbsOptimization = map[string]bool{
+	"t1": true, "t2": true, "m1": true, "m2": true, "m3": true,
+	"c1": true, "c3": true, "cc2": true, "cr1": true, "r3": true,
+	"i2": true, "g2": true, "hs1": true,
+}
+
+// ec2ArmFamilyRe matches the EC2 instance families with AWS Graviton (arm64) processors, eg. m6g, c6gn, r6gd, t4g or a1
+var ec2ArmFamilyRe = regexp.MustCompile(`^(a1|[a-z]+[0-9]+g[a-z]*)$`)
+
+// ec2Family returns the family of the EC2 instance type, eg. m5 for m5.xlarge
+func ec2Family(vmType string) string {
+	return strings.SplitN(vmType, ".", 2)[0]
+}
+
+// enaSupport checks whether the instance type supports the Elastic Network Adapter, it's only known for amazon
+func enaSupport(provider, vmType string) bool {
+	return provider == "amazon" && !ec2FamiliesWithoutEna[ec2Family(vmType)]
+}
+
+// ebsOptimized checks whether the instance type is EBS-optimized by default, it's only known for amazon
+func ebsOptimized(provider, vmType string) bool {
+	return provider == "amazon" && !ec2FamiliesWithoutEbsOptimization[ec2Family(vmType)]
+}
+
+// architecture returns the cpu architecture of the instance type, the arm64 families are only known for amazon
+func architecture(provider, vmType string) string {
+	if provider == "amazon" && ec2ArmFamilyRe.MatchString(ec2Family(vmType)) {
+		return ArmArchitecture
+	}
+	return X86Architecture
+}
@@ -0,0 +1,73 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"strings"
+)
+
+// eniLimit holds the maximum number of network interfaces of an instance type and the private IPv4 addresses per interface
+type eniLimit struct {
+	enis      int
+	ipsPerEni int
+}
+
+// eniLimitsBySize holds the network interface limits of the EC2 instance sizes, they are the same across most of the families
+var eniLimitsBySize = map[string]eniLimit{
+	"nano":     {2, 2},
+	"micro":    {2, 2},
+	"small":    {3, 4},
+	"medium":   {3, 6},
+	"large":    {3, 10},
+	"xlarge":   {4, 15},
+	"2xlarge":  {4, 15},
+	"4xlarge":  {8, 30},
+	"8xlarge":  {8, 30},
+	"9xlarge":  {8, 30},
+	"10xlarge": {8, 30},
+	"12xlarge": {8, 30},
+	"16xlarge": {15, 50},
+	"18xlarge": {15, 50},
+	"24xlarge": {15, 50},
+	"32xlarge": {15, 50},
+	"metal":    {15, 50},
+}
+
+// eniLimitsByType holds the network interface limits of the EC2 instance types deviating from the limits of their size
+var eniLimitsByType = map[string]eniLimit{
+	"t2.large":   {3, 12},
+	"t2.xlarge":  {3, 15},
+	"t2.2xlarge": {3, 15},
+	"t3.large":   {3, 12},
+	"t3a.large":  {3, 12},
+}
+
+// ipCapacity returns the maximum number of private IP addresses of the instance type, 0 if unknown
+// it's only known for amazon, where it's the number of network interfaces multiplied by the addresses per interface
+func ipCapacity(provider, vmType string) int {
+	if provider != "amazon" {
+		return 0
+	}
+
+	limit, ok := eniLimitsByType[vmType]
+	if !ok {
+		parts := strings.SplitN(vmType, ".", 2)
+		if len(parts) != 2 {
+			return 0
+		}
+		limit = eniLimitsBySize[parts[1]]
+	}
+	return limit.enis * limit.ipsPerEni
+}
@@ -0,0 +1,120 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// memoryUnits maps the unit suffixes of memory quantities to their size in GiB
+var memoryUnits = map[string]float64{
+	"Ki": 1 / math.Pow(1024, 2),
+	"Mi": 1 / 1024.0,
+	"Gi": 1,
+	"Ti": 1024,
+	"K":  1e3 / math.Pow(1024, 3),
+	"M":  1e6 / math.Pow(1024, 3),
+	"G":  1e9 / math.Pow(1024, 3),
+	"T":  1e12 / math.Pow(1024, 3),
+	"KB": 1e3 / math.Pow(1024, 3),
+	"MB": 1e6 / math.Pow(1024, 3),
+	"GB": 1e9 / math.Pow(1024, 3),
+	"TB": 1e12 / math.Pow(1024, 3),
+}
+
+// ParseMemory parses a memory quantity with a binary (Ki, Mi, Gi, Ti) or decimal (K, M, G, T or KB, MB, GB, TB) unit suffix to GiB,
+// eg. 16Gi, 32G, 32GB or 65536Mi, optionally separated by spaces; a quantity without a unit is taken as GiB
+func ParseMemory(quantity string) (float64, error) {
+	quantity = strings.TrimSpace(quantity)
+	value, multiplier := quantity, 1.0
+	for unit, m := range memoryUnits {
+		// at most one unit matches: the binary units end with i, the decimal ones with the prefix or B
+		if strings.HasSuffix(quantity, unit) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(quantity, unit)), m
+			break
+		}
+	}
+
+	mem, err := strconv.ParseFloat(value, 64)
+	if err != nil || mem < 0 || math.IsInf(mem, 0) || math.IsNaN(mem) {
+		return 0, errors.Errorf("invalid memory quantity: %q", quantity)
+	}
+	return mem * multiplier, nil
+}
+
+// unmarshalMemory unmarshals a memory field given as a number (GiB) or as a string with a unit suffix
+func unmarshalMemory(raw json.RawMessage) (float64, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	var quantity string
+	if err := json.Unmarshal(raw, &quantity); err == nil {
+		return ParseMemory(quantity)
+	}
+
+	var mem float64
+	if err := json.Unmarshal(raw, &mem); err != nil {
+		return 0, errors.Errorf("invalid memory quantity: %s", raw)
+	}
+	return mem, nil
+}
+
+// UnmarshalJSON unmarshals the request accepting the memory as a number (GiB) or as a string with a unit suffix
+func (r *ClusterRecommendationReq) UnmarshalJSON(data []byte) error {
+	type plain ClusterRecommendationReq
+	req := struct {
+		*plain
+		SumMem json.RawMessage `json:"sumMem"`
+	}{plain: (*plain)(r)}
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+
+	mem, err := unmarshalMemory(req.SumMem)
+	if err != nil {
+		return errors.Wrap(err, "sumMem")
+	}
+	r.SumMem = mem
+
+	return nil
+}
+
+// UnmarshalJSON unmarshals the request accepting the memory as a number (GiB) or as a string with a unit suffix
+func (r *ClusterScaleoutRecommendationReq) UnmarshalJSON(data []byte) error {
+	type plain ClusterScaleoutRecommendationReq
+	req := struct {
+		*plain
+		DesiredMem json.RawMessage `json:"desiredMem"`
+	}{plain: (*plain)(r)}
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+
+	mem, err := unmarshalMemory(req.DesiredMem)
+	if err != nil {
+		return errors.Wrap(err, "desiredMem")
+	}
+	r.DesiredMem = mem
+
+	return nil
+}
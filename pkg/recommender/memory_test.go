@@ -0,0 +1,153 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMemory(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity string
+		check    func(mem float64, err error)
+	}{
+		{
+			name:     "binary gigabytes",
+			quantity: "16Gi",
+			check: func(mem float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, float64(16), mem)
+			},
+		},
+		{
+			name:     "binary megabytes",
+			quantity: "65536Mi",
+			check: func(mem float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, float64(64), mem)
+			},
+		},
+		{
+			name:     "binary terabytes",
+			quantity: "0.5Ti",
+			check: func(mem float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, float64(512), mem)
+			},
+		},
+		{
+			name:     "decimal gigabytes",
+			quantity: "32G",
+			check: func(mem float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.InDelta(t, 29.8, mem, 0.01)
+			},
+		},
+		{
+			name:     "no unit means gigabytes",
+			quantity: "8",
+			check: func(mem float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, float64(8), mem)
+			},
+		},
+		{
+			name:     "decimal gigabytes with B",
+			quantity: "16GB",
+			check: func(mem float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.InDelta(t, 14.9, mem, 0.01)
+			},
+		},
+		{
+			name:     "decimal megabytes with B",
+			quantity: "512MB",
+			check: func(mem float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.InDelta(t, 0.477, mem, 0.001)
+			},
+		},
+		{
+			name:     "space before the unit",
+			quantity: "16 Gi",
+			check: func(mem float64, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, float64(16), mem)
+			},
+		},
+		{
+			name:     "unknown unit",
+			quantity: "16GiB",
+			check: func(mem float64, err error) {
+				assert.EqualError(t, err, `invalid memory quantity: "16GiB"`)
+			},
+		},
+		{
+			name:     "not a number",
+			quantity: "lotsGi",
+			check: func(mem float64, err error) {
+				assert.EqualError(t, err, `invalid memory quantity: "lotsGi"`)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(ParseMemory(test.quantity))
+		})
+	}
+}
+
+func TestClusterRecommendationReq_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		check func(req ClusterRecommendationReq, err error)
+	}{
+		{
+			name: "memory as a number",
+			json: `{"sumCpu": 8, "sumMem": 32, "minNodes": 1, "maxNodes": 2}`,
+			check: func(req ClusterRecommendationReq, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2}, req)
+			},
+		},
+		{
+			name: "memory with a unit",
+			json: `{"sumCpu": 8, "sumMem": "32768Mi", "minNodes": 1, "maxNodes": 2}`,
+			check: func(req ClusterRecommendationReq, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2}, req)
+			},
+		},
+		{
+			name: "invalid memory",
+			json: `{"sumCpu": 8, "sumMem": "32 gigs"}`,
+			check: func(req ClusterRecommendationReq, err error) {
+				assert.EqualError(t, err, `sumMem: invalid memory quantity: "32 gigs"`)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var req ClusterRecommendationReq
+			err := json.Unmarshal([]byte(test.json), &req)
+			test.check(req, err)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"math"
+
+	"github.com/goph/emperror"
+	"github.com/pkg/errors"
+)
+
+// recommendNodeCount recommends the requested number of nodes of the cheapest instance type meeting the per node requirements
+// the nodes are split into a regular and a spot node pool of the same instance type by the on-demand percentage
+func (e *Engine) recommendNodeCount(provider string, req ClusterRecommendationReq, allProducts []VirtualMachine) ([]NodePool, error) {
+	odVms, spotVms, err := e.vmSelector.RecommendVms(provider, allProducts, Cpu, req, nil)
+	if err != nil {
+		return nil, emperror.Wrap(err, "failed to recommend virtual machines")
+	}
+
+	odNodes := int(math.Ceil(float64(req.NodeCount*req.OnDemandPct) / 100))
+	spotNodes := req.NodeCount - odNodes
+
+	spotPrices := make(map[string]float64)
+	for _, vm := range spotVms {
+		spotPrices[vm.Type] = vm.AvgPrice
+	}
+
+	candidates := odVms
+	if odNodes == 0 {
+		candidates = spotVms
+	}
+
+	var (
+		cheapest      *VirtualMachine
+		cheapestPrice float64
+	)
+	for i, vm := range candidates {
+		spotPrice, ok := spotPrices[vm.Type]
+		if spotNodes > 0 && !ok {
+			continue
+		}
+		price := float64(odNodes)*vm.OnDemandPrice + float64(spotNodes)*spotPrice
+		if cheapest == nil || price < cheapestPrice {
+			cheapest, cheapestPrice = &candidates[i], price
+		}
+	}
+
+	if cheapest == nil {
+		return nil, emperror.With(errors.New("no instance type meets the per node requirements"), RecommenderErrorTag, "nodeCount")
+	}
+
+	var nodePools []NodePool
+	if odNodes > 0 {
+		nodePools = append(nodePools, NodePool{VmType: *cheapest, SumNodes: odNodes, VmClass: Regular, Role: Worker})
+	}
+	if spotNodes > 0 {
+		nodePools = append(nodePools, NodePool{VmType: *cheapest, SumNodes: spotNodes, VmClass: Spot, Role: Worker})
+	}
+
+	return nodePools, nil
+}
@@ -0,0 +1,110 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"testing"
+
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_RecommendClusterNodeCount(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.08},
+		{Type: "c5.xlarge", Cpus: 4, Mem: 8, OnDemandPrice: 0.17, AvgPrice: 0.07},
+		{Type: "m5.large", Cpus: 2, Mem: 8, OnDemandPrice: 0.096, AvgPrice: 0.04},
+	}
+	tests := []struct {
+		name  string
+		req   ClusterRecommendationReq
+		check func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name: "cheapest type meeting the per node requirements multiplied",
+			req:  ClusterRecommendationReq{NodeCount: 10, MinVcpu: 4, MinMem: 16, OnDemandPct: 100},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Len(t, resp.NodePools, 1)
+				assert.Equal(t, "m5.xlarge", resp.NodePools[0].VmType.Type)
+				assert.Equal(t, 10, resp.NodePools[0].SumNodes)
+				assert.Equal(t, 10, resp.Accuracy.RecNodes)
+			},
+		},
+		{
+			name: "nodes split by the on-demand percentage",
+			req:  ClusterRecommendationReq{NodeCount: 10, MinVcpu: 4, OnDemandPct: 30},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Len(t, resp.NodePools, 2)
+				assert.Equal(t, "c5.xlarge", resp.NodePools[0].VmType.Type)
+				assert.Equal(t, Regular, resp.NodePools[0].VmClass)
+				assert.Equal(t, 3, resp.NodePools[0].SumNodes)
+				assert.Equal(t, "c5.xlarge", resp.NodePools[1].VmType.Type)
+				assert.Equal(t, Spot, resp.NodePools[1].VmClass)
+				assert.Equal(t, 7, resp.NodePools[1].SumNodes)
+			},
+		},
+		{
+			name: "capacity target",
+			req:  ClusterRecommendationReq{MinNodes: 1, MaxNodes: 8, SumCpu: 8, SumMem: 32, OnDemandPct: 100},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, 8.0, resp.Accuracy.RecCpu)
+			},
+		},
+		{
+			name: "both targets",
+			req:  ClusterRecommendationReq{MinNodes: 1, MaxNodes: 8, SumCpu: 8, SumMem: 32, NodeCount: 10},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, "either sumCpu and sumMem or nodeCount can be requested, not both")
+				assert.Contains(t, emperror.Context(err), RecommenderErrorTag)
+				assert.Nil(t, resp)
+			},
+		},
+		{
+			name: "no target",
+			req:  ClusterRecommendationReq{MinNodes: 1, MaxNodes: 8},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, "either sumCpu and sumMem or nodeCount must be requested")
+				assert.Contains(t, emperror.Context(err), RecommenderErrorTag)
+				assert.Nil(t, resp)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &perNodeVms{}, &cheapestOnDemandPool{}, nil, EngineConfig{})
+
+			test.check(engine.RecommendCluster("amazon", "compute", "eu-west-1", test.req, nil))
+		})
+	}
+}
+
+// perNodeVms recommends the vms meeting the per node cpu and memory minimums for regular and spot node pools
+type perNodeVms struct {
+	passThroughVms
+}
+
+func (v *perNodeVms) RecommendVms(provider string, vms []VirtualMachine, attr string, req ClusterRecommendationReq, layout []NodePool) ([]VirtualMachine, []VirtualMachine, error) {
+	var filtered []VirtualMachine
+	for _, vm := range vms {
+		if vm.Cpus >= req.MinVcpu && vm.Mem >= req.MinMem {
+			filtered = append(filtered, vm)
+		}
+	}
+	return filtered, filtered, nil
+}
@@ -24,7 +24,8 @@ import (
 )
 
 type nodePoolSelector struct {
-	log logur.Logger
+	log    logur.Logger
+	scorer recommender.Scorer
 }
 
 func NewNodePoolSelector(log logur.Logger) *nodePoolSelector {
@@ -33,6 +34,13 @@ func NewNodePoolSelector(log logur.Logger) *nodePoolSelector {
 	}
 }
 
+// WithScorer returns a copy of the selector ranking the spot instance types by the scorer instead of the objective of the requests
+func (s *nodePoolSelector) WithScorer(scorer recommender.Scorer) recommender.NodePoolRecommender {
+	selector := *s
+	selector.scorer = scorer
+	return &selector
+}
+
 // RecommendNodePools finds the slice of NodePools that may participate in the recommendation process
 func (s *nodePoolSelector) RecommendNodePools(attr string, req recommender.ClusterRecommendationReq, layout []recommender.NodePool, odVms []recommender.VirtualMachine, spotVms []recommender.VirtualMachine) []recommender.NodePool {
 	s.log.Debug(fmt.Sprintf("requested sum for attribute [%s]: [%f]", attr, sum(req, attr)))
@@ -54,7 +62,8 @@ func (s *nodePoolSelector) RecommendNodePools(attr string, req recommender.Clust
 		// find cheapest onDemand instance from the list - based on price per attribute
 		selectedOnDemand := odVms[0]
 		for _, vm := range odVms {
-			if vm.OnDemandPrice/vm.GetAttrValue(attr) < selectedOnDemand.OnDemandPrice/selectedOnDemand.GetAttrValue(attr) {
+			price, selectedPrice := vm.OnDemandPrice/vm.GetAttrValue(attr), selectedOnDemand.OnDemandPrice/selectedOnDemand.GetAttrValue(attr)
+			if price < selectedPrice || price == selectedPrice && breaksTie(attr, vm, selectedOnDemand) {
 				selectedOnDemand = vm
 			}
 		}
@@ -86,12 +95,14 @@ func (s *nodePoolSelector) RecommendNodePools(attr string, req recommender.Clust
 		// recommend spot pools
 		excludedSpotNps := make([]recommender.NodePool, 0)
 
-		s.sortByAttrValue(attr, spotVms)
+		s.rankSpotVms(attr, req, spotVms)
 
 		var N int
 		if layout == nil {
 			// the "magic" number of machines for diversifying the types
 			N = int(math.Min(float64(findN(avgSpotNodeCount(req.MinNodes, req.MaxNodes, odNodesToAdd))), float64(len(spotVms))))
+			// at least the requested number of types is used
+			N = int(math.Min(math.Max(float64(N), float64(req.MinInstanceTypes)), float64(len(spotVms))))
 			// the second "magic" number for diversifying the layout
 			M := findM(N, spotVms)
 			s.log.Debug(fmt.Sprintf("Magic 'Marton' numbers: N=%d, M=%d", N, M))
@@ -108,6 +119,12 @@ func (s *nodePoolSelector) RecommendNodePools(attr string, req recommender.Clust
 					Role:     recommender.Worker,
 				})
 			}
+
+			// each of the requested number of types gets a node before the pools are filled
+			for i := 0; i < req.MinInstanceTypes && i < N; i++ {
+				spotNps[i].SumNodes = 1
+				sumSpotValue -= spotNps[i].VmType.GetAttrValue(attr)
+			}
 		} else {
 			sort.Sort(ByNonZeroNodePools(layout))
 			var nonZeroNPs int
@@ -143,6 +160,107 @@ func (s *nodePoolSelector) RecommendNodePools(attr string, req recommender.Clust
 	return append(odNps, spotNps...)
 }
 
+// rankSpotVms sorts the spot vms by the custom scorer if the selector has one, by the objective of the request otherwise
+func (s *nodePoolSelector) rankSpotVms(attr string, req recommender.ClusterRecommendationReq, vms []recommender.VirtualMachine) {
+	if s.scorer == nil {
+		s.sortByObjective(attr, req.Objective, vms)
+		return
+	}
+
+	scores := make([]float64, len(vms))
+	for i, vm := range vms {
+		scores[i] = s.scorer.Score(vm, req)
+	}
+	sortByScore(attr, vms, scores)
+}
+
+// sortByObjective sorts the vms by their score for the given objective, the best scored vm first
+func (s *nodePoolSelector) sortByObjective(attr string, objective string, vms []recommender.VirtualMachine) {
+	switch objective {
+	case recommender.Stability:
+		// prefer the lower interruption ratings, vms without a rating come last
+		// a smaller spot discount signals a lower interruption risk among equally rated vms
+		sort.SliceStable(vms, func(i, j int) bool {
+			ri, rj := interruptionRank(vms[i]), interruptionRank(vms[j])
+			if ri != rj {
+				return ri < rj
+			}
+			if di, dj := spotDiscount(vms[i]), spotDiscount(vms[j]); di != dj {
+				return di < dj
+			}
+			return breaksTie(attr, vms[i], vms[j])
+		})
+	case recommender.Balanced:
+		sortByScore(attr, vms, balancedScores(attr, vms))
+	default:
+		s.sortByAttrValue(attr, vms)
+	}
+}
+
+// balancedScores combines the price per attribute (normalized to the most expensive vm) and the spot discount of the vms
+func balancedScores(attr string, vms []recommender.VirtualMachine) []float64 {
+	var maxPrice float64
+	for _, vm := range vms {
+		maxPrice = math.Max(maxPrice, vm.AvgPrice/vm.GetAttrValue(attr))
+	}
+
+	scores := make([]float64, len(vms))
+	for i, vm := range vms {
+		var costScore float64
+		if maxPrice > 0 {
+			costScore = vm.AvgPrice / vm.GetAttrValue(attr) / maxPrice
+		}
+		scores[i] = (costScore + spotDiscount(vm)) / 2
+	}
+	return scores
+}
+
+// sortByScore sorts the vms in increasing order of the scores belonging to them, equal scores are ordered by breaksTie
+func sortByScore(attr string, vms []recommender.VirtualMachine, scores []float64) {
+	idx := make([]int, len(vms))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		if scores[idx[i]] != scores[idx[j]] {
+			return scores[idx[i]] < scores[idx[j]]
+		}
+		return breaksTie(attr, vms[idx[i]], vms[idx[j]])
+	})
+
+	sorted := make([]recommender.VirtualMachine, len(vms))
+	for i, vmIdx := range idx {
+		sorted[i] = vms[vmIdx]
+	}
+	copy(vms, sorted)
+}
+
+// breaksTie orders the vms ranked equally, so identical inputs always yield identical recommendations:
+// the vm with the lower attribute value comes first (less resources are wasted when the node count is rounded up),
+// then the one with the alphabetically lower instance type
+func breaksTie(attr string, vm1, vm2 recommender.VirtualMachine) bool {
+	if v1, v2 := vm1.GetAttrValue(attr), vm2.GetAttrValue(attr); v1 != v2 {
+		return v1 < v2
+	}
+	return vm1.Type < vm2.Type
+}
+
+// interruptionRank returns the interruption rating of the vm, unknown ratings rank after all the known ones
+func interruptionRank(vm recommender.VirtualMachine) int {
+	if vm.InterruptionRating == 0 {
+		return math.MaxInt32
+	}
+	return vm.InterruptionRating
+}
+
+// spotDiscount returns the discount of the spot price compared to the on-demand price, as a ratio
+func spotDiscount(vm recommender.VirtualMachine) float64 {
+	if vm.OnDemandPrice == 0 {
+		return 0
+	}
+	return 1 - vm.AvgPrice/vm.OnDemandPrice
+}
+
 // sortByAttrValue returns the slice for
 func (s *nodePoolSelector) sortByAttrValue(attr string, vms []recommender.VirtualMachine) {
 	// sort and cut
@@ -164,7 +282,10 @@ func (a ByAvgPricePerCpu) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a ByAvgPricePerCpu) Less(i, j int) bool {
 	pricePerCpu1 := a[i].AvgPrice / a[i].Cpus
 	pricePerCpu2 := a[j].AvgPrice / a[j].Cpus
-	return pricePerCpu1 < pricePerCpu2
+	if pricePerCpu1 != pricePerCpu2 {
+		return pricePerCpu1 < pricePerCpu2
+	}
+	return breaksTie(recommender.Cpu, a[i], a[j])
 }
 
 // ByAvgPricePerMemory type for custom sorting of a slice of vms
@@ -175,7 +296,10 @@ func (a ByAvgPricePerMemory) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a ByAvgPricePerMemory) Less(i, j int) bool {
 	pricePerMem1 := a[i].AvgPrice / a[i].Mem
 	pricePerMem2 := a[j].AvgPrice / a[j].Mem
-	return pricePerMem1 < pricePerMem2
+	if pricePerMem1 != pricePerMem2 {
+		return pricePerMem1 < pricePerMem2
+	}
+	return breaksTie(recommender.Memory, a[i], a[j])
 }
 
 type ByNonZeroNodePools []recommender.NodePool
@@ -17,6 +17,8 @@ package nodepools
 import (
 	"testing"
 
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/logur"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,3 +64,187 @@ func Test_avgSpotNodeCount(t *testing.T) {
 		})
 	}
 }
+
+func TestNodePoolSelector_sortByObjective(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "cheapest", Cpus: 4, OnDemandPrice: 1, AvgPrice: 0.2},
+		{Type: "most-stable", Cpus: 4, OnDemandPrice: 1, AvgPrice: 0.6},
+		{Type: "balanced", Cpus: 4, OnDemandPrice: 0.5, AvgPrice: 0.21},
+	}
+	tests := []struct {
+		name      string
+		objective string
+		vms       func(vms []recommender.VirtualMachine) []recommender.VirtualMachine
+		check     func(vms []recommender.VirtualMachine)
+	}{
+		{
+			name:      "cost is the default objective",
+			objective: "",
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, "cheapest", vms[0].Type)
+			},
+		},
+		{
+			name:      "cost objective",
+			objective: recommender.Cost,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, "cheapest", vms[0].Type)
+			},
+		},
+		{
+			name:      "stability objective",
+			objective: recommender.Stability,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, "most-stable", vms[0].Type)
+			},
+		},
+		{
+			name:      "stability objective with interruption ratings",
+			objective: recommender.Stability,
+			vms: func(vms []recommender.VirtualMachine) []recommender.VirtualMachine {
+				vms[0].InterruptionRating = 1
+				vms[1].InterruptionRating = 2
+				return vms
+			},
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, []string{"cheapest", "most-stable", "balanced"}, []string{vms[0].Type, vms[1].Type, vms[2].Type})
+			},
+		},
+		{
+			name:      "balanced objective",
+			objective: recommender.Balanced,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, "balanced", vms[0].Type)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewNodePoolSelector(logur.NewTestLogger())
+			sorted := make([]recommender.VirtualMachine, len(vms))
+			copy(sorted, vms)
+			if test.vms != nil {
+				sorted = test.vms(sorted)
+			}
+			selector.sortByObjective(recommender.Cpu, test.objective, sorted)
+			test.check(sorted)
+		})
+	}
+}
+
+func TestNodePoolSelector_sortByObjectiveTies(t *testing.T) {
+	// equally priced per cpu: the smaller instance type wins, then the alphabetically lower one
+	vms := []recommender.VirtualMachine{
+		{Type: "m5a.xlarge", Cpus: 4, OnDemandPrice: 0.172, AvgPrice: 0.08},
+		{Type: "c5.2xlarge", Cpus: 8, OnDemandPrice: 0.344, AvgPrice: 0.16},
+		{Type: "m5.xlarge", Cpus: 4, OnDemandPrice: 0.172, AvgPrice: 0.08},
+	}
+	for _, objective := range []string{recommender.Cost, recommender.Stability, recommender.Balanced} {
+		t.Run(objective, func(t *testing.T) {
+			selector := NewNodePoolSelector(logur.NewTestLogger())
+			for _, order := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 2, 0}} {
+				sorted := make([]recommender.VirtualMachine, 0, len(vms))
+				for _, i := range order {
+					sorted = append(sorted, vms[i])
+				}
+				selector.sortByObjective(recommender.Cpu, objective, sorted)
+				assert.Equal(t, []string{"m5.xlarge", "m5a.xlarge", "c5.2xlarge"}, []string{sorted[0].Type, sorted[1].Type, sorted[2].Type})
+			}
+		})
+	}
+}
+
+// invertedScorer prefers the most expensive instance types per cpu
+type invertedScorer struct{}
+
+func (invertedScorer) Score(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) float64 {
+	return -recommender.PriceScorer{}.Score(vm, req)
+}
+
+func TestNodePoolSelector_WithScorer(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "cheapest", Cpus: 4, OnDemandPrice: 1, AvgPrice: 0.2},
+		{Type: "priciest", Cpus: 4, OnDemandPrice: 1, AvgPrice: 0.6},
+		{Type: "middle", Cpus: 4, OnDemandPrice: 1, AvgPrice: 0.4},
+	}
+	tests := []struct {
+		name   string
+		scorer recommender.Scorer
+		check  func(vms []recommender.VirtualMachine)
+	}{
+		{
+			name:   "the price scorer ranks as the cost objective",
+			scorer: recommender.PriceScorer{},
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, []string{"cheapest", "middle", "priciest"}, []string{vms[0].Type, vms[1].Type, vms[2].Type})
+			},
+		},
+		{
+			name:   "an inverted scorer flips the ranking",
+			scorer: invertedScorer{},
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, []string{"priciest", "middle", "cheapest"}, []string{vms[0].Type, vms[1].Type, vms[2].Type})
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewNodePoolSelector(logur.NewTestLogger())
+			scoring := selector.WithScorer(test.scorer).(*nodePoolSelector)
+			assert.Nil(t, selector.scorer)
+
+			sorted := make([]recommender.VirtualMachine, len(vms))
+			copy(sorted, vms)
+			scoring.rankSpotVms(recommender.Cpu, recommender.ClusterRecommendationReq{}, sorted)
+			test.check(sorted)
+		})
+	}
+}
+
+func TestNodePoolSelector_RecommendNodePoolsMinInstanceTypes(t *testing.T) {
+	spotVms := []recommender.VirtualMachine{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, AvgPrice: 0.07, OnDemandPrice: 0.192},
+		{Type: "m4.xlarge", Cpus: 4, Mem: 16, AvgPrice: 0.08, OnDemandPrice: 0.2},
+		{Type: "c5.xlarge", Cpus: 4, Mem: 8, AvgPrice: 0.09, OnDemandPrice: 0.17},
+		{Type: "c4.xlarge", Cpus: 4, Mem: 7.5, AvgPrice: 0.1, OnDemandPrice: 0.199},
+	}
+	tests := []struct {
+		name  string
+		req   recommender.ClusterRecommendationReq
+		check func(nps []recommender.NodePool)
+	}{
+		{
+			name: "single type without diversification",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 16, MinNodes: 1, MaxNodes: 1},
+			check: func(nps []recommender.NodePool) {
+				assert.Equal(t, 1, countTypesWithNodes(nps))
+			},
+		},
+		{
+			name: "spot nodes spread across the requested number of types",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 16, MinNodes: 1, MaxNodes: 1, MinInstanceTypes: 3},
+			check: func(nps []recommender.NodePool) {
+				assert.Equal(t, 3, countTypesWithNodes(nps))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewNodePoolSelector(logur.NewTestLogger())
+			vms := make([]recommender.VirtualMachine, len(spotVms))
+			copy(vms, spotVms)
+			test.check(selector.RecommendNodePools(recommender.Cpu, test.req, nil, nil, vms))
+		})
+	}
+}
+
+// countTypesWithNodes counts the distinct instance types of the node pools with nodes
+func countTypesWithNodes(nps []recommender.NodePool) int {
+	types := make(map[string]bool)
+	for _, np := range nps {
+		if np.SumNodes > 0 {
+			types[np.VmType.Type] = true
+		}
+	}
+	return len(types)
+}
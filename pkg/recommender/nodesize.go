@@ -0,0 +1,99 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// nodeSizeBounds holds the per node cpu and memory (GB) bounds an abstract node size stands for, 0 means unbounded
+type nodeSizeBounds struct {
+	minVcpu float64
+	maxVcpu float64
+	minMem  float64
+	maxMem  float64
+}
+
+// defaultNodeSizes holds the bounds of the abstract node sizes for the providers without their own mapping
+var defaultNodeSizes = map[string]nodeSizeBounds{
+	"small":  {1, 2, 1, 8},
+	"medium": {2, 4, 4, 32},
+	"large":  {8, 16, 16, 128},
+	"xlarge": {32, 0, 64, 0},
+}
+
+// nodeSizes holds the bounds of the abstract node sizes per provider, the sizes missing here fall back to the defaults
+var nodeSizes = map[string]map[string]nodeSizeBounds{
+	"amazon": {
+		// the burstable small, medium and large sizes have 0.5 - 8 GB memory
+		"small":  {1, 2, 0.5, 8},
+		"medium": {2, 4, 4, 32},
+	},
+	"google": {
+		// the shared core machine types have a fraction of a cpu
+		"small": {0.2, 2, 0.6, 8},
+	},
+}
+
+// nodeSizeSpecRe matches the node size specs listing the exact cpus and memory, eg. 4vcpu-16gb
+var nodeSizeSpecRe = regexp.MustCompile(`^(\d+(?:\.\d+)?)vcpu-(\d+(?:\.\d+)?)gb$`)
+
+// lookupNodeSize returns the bounds of the node size on the provider
+func lookupNodeSize(provider, size string) (nodeSizeBounds, error) {
+	if bounds, ok := nodeSizes[provider][size]; ok {
+		return bounds, nil
+	}
+	if bounds, ok := defaultNodeSizes[size]; ok {
+		return bounds, nil
+	}
+
+	match := nodeSizeSpecRe.FindStringSubmatch(size)
+	if match == nil {
+		return nodeSizeBounds{}, errors.Errorf("unknown node size: %s", size)
+	}
+	// the expression only matches valid numbers
+	cpus, _ := strconv.ParseFloat(match[1], 64)
+	mem, _ := strconv.ParseFloat(match[2], 64)
+	return nodeSizeBounds{cpus, cpus, mem, mem}, nil
+}
+
+// resolveNodeSize sets the per node bounds of the requested node size that are not requested explicitly
+func resolveNodeSize(provider string, req ClusterRecommendationReq) (ClusterRecommendationReq, error) {
+	if req.NodeSize == "" {
+		return req, nil
+	}
+
+	bounds, err := lookupNodeSize(provider, req.NodeSize)
+	if err != nil {
+		return req, err
+	}
+
+	if req.MinVcpu == 0 {
+		req.MinVcpu = bounds.minVcpu
+	}
+	if req.MaxVcpu == 0 {
+		req.MaxVcpu = bounds.maxVcpu
+	}
+	if req.MinMem == 0 {
+		req.MinMem = bounds.minMem
+	}
+	if req.MaxMem == 0 {
+		req.MaxMem = bounds.maxMem
+	}
+	return req, nil
+}
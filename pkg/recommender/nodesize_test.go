@@ -0,0 +1,100 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_resolveNodeSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		req      ClusterRecommendationReq
+		check    func(req ClusterRecommendationReq, err error)
+	}{
+		{
+			name:     "medium on amazon",
+			provider: "amazon",
+			req:      ClusterRecommendationReq{NodeSize: "medium"},
+			check: func(req ClusterRecommendationReq, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2.0, req.MinVcpu)
+				assert.Equal(t, 4.0, req.MaxVcpu)
+				assert.Equal(t, 4.0, req.MinMem)
+				assert.Equal(t, 32.0, req.MaxMem)
+			},
+		},
+		{
+			name:     "provider specific size",
+			provider: "google",
+			req:      ClusterRecommendationReq{NodeSize: "small"},
+			check: func(req ClusterRecommendationReq, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 0.2, req.MinVcpu)
+				assert.Equal(t, 2.0, req.MaxVcpu)
+			},
+		},
+		{
+			name:     "default size on a provider without mapping",
+			provider: "oracle",
+			req:      ClusterRecommendationReq{NodeSize: "xlarge"},
+			check: func(req ClusterRecommendationReq, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 32.0, req.MinVcpu)
+				assert.Equal(t, 0.0, req.MaxVcpu)
+				assert.Equal(t, 64.0, req.MinMem)
+				assert.Equal(t, 0.0, req.MaxMem)
+			},
+		},
+		{
+			name:     "explicit cpus and memory",
+			provider: "azure",
+			req:      ClusterRecommendationReq{NodeSize: "4vcpu-16gb"},
+			check: func(req ClusterRecommendationReq, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 4.0, req.MinVcpu)
+				assert.Equal(t, 4.0, req.MaxVcpu)
+				assert.Equal(t, 16.0, req.MinMem)
+				assert.Equal(t, 16.0, req.MaxMem)
+			},
+		},
+		{
+			name:     "explicit bounds take precedence",
+			provider: "amazon",
+			req:      ClusterRecommendationReq{NodeSize: "medium", MaxVcpu: 8},
+			check: func(req ClusterRecommendationReq, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, 2.0, req.MinVcpu)
+				assert.Equal(t, 8.0, req.MaxVcpu)
+			},
+		},
+		{
+			name:     "unknown size",
+			provider: "amazon",
+			req:      ClusterRecommendationReq{NodeSize: "huge"},
+			check: func(req ClusterRecommendationReq, err error) {
+				assert.EqualError(t, err, "unknown node size: huge")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(resolveNodeSize(test.provider, test.req))
+		})
+	}
+}
@@ -15,6 +15,13 @@
 package recommender
 
 import (
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
 	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/client"
 	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/client/continents"
 	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/client/products"
@@ -27,6 +34,15 @@ import (
 	"github.com/goph/emperror"
 )
 
+// storageAttr is the product attribute describing the local storage of the instance type
+const storageAttr = "storage"
+
+// vcpusPerOcpu is the number of vCPUs an Oracle Cloud OCPU (a physical core with two hardware threads) stands for
+const vcpusPerOcpu = 2
+
+// storageRe matches the disk count (optional) and the size of a disk in the storage attribute
+var storageRe = regexp.MustCompile(`^\s*(?:(\d+)\s*x\s*)?(\d+(?:\.\d+)?)`)
+
 // CloudInfoSource declares operations for retrieving information required for the recommender engine
 type CloudInfoSource interface {
 	// GetProductDetails retrieves the product details for the provider and region
@@ -36,10 +52,38 @@ type CloudInfoSource interface {
 	GetRegions(provider, service string) ([]*models.Continent, error)
 }
 
+// ContextCloudInfoSource is a CloudInfoSource the callers can stop waiting for by cancelling their context,
+// eg. when the deadline of the request passed
+type ContextCloudInfoSource interface {
+	// GetProductDetailsContext retrieves the product details for the provider and region within the context
+	GetProductDetailsContext(ctx context.Context, provider string, service string, region string) ([]VirtualMachine, error)
+
+	// GetRegionsContext retrieves the regions within the context
+	GetRegionsContext(ctx context.Context, provider, service string) ([]*models.Continent, error)
+}
+
+// ProductDetails retrieves the product details from the source, within the context if the source supports it
+func ProductDetails(ctx context.Context, source CloudInfoSource, provider string, service string, region string) ([]VirtualMachine, error) {
+	if s, ok := source.(ContextCloudInfoSource); ok {
+		return s.GetProductDetailsContext(ctx, provider, service, region)
+	}
+	return source.GetProductDetails(provider, service, region)
+}
+
+// Regions retrieves the regions from the source, within the context if the source supports it
+func Regions(ctx context.Context, source CloudInfoSource, provider, service string) ([]*models.Continent, error) {
+	if s, ok := source.(ContextCloudInfoSource); ok {
+		return s.GetRegionsContext(ctx, provider, service)
+	}
+	return source.GetRegions(provider, service)
+}
+
 // CloudInfoClient application struct to retrieve data for the recommender; wraps the generated product info client
 // It implements the CloudInfoSource interface, delegates to the embedded generated client
 type CloudInfoClient struct {
 	*client.Cloudinfo
+	// timeout of the calls to the cloud info service
+	timeout time.Duration
 }
 
 const (
@@ -48,13 +92,13 @@ const (
 )
 
 // NewCloudInfoClient creates a new product info client wrapper instance
-func NewCloudInfoClient(pic *client.Cloudinfo) *CloudInfoClient {
-	return &CloudInfoClient{Cloudinfo: pic}
+func NewCloudInfoClient(pic *client.Cloudinfo, timeout time.Duration) *CloudInfoClient {
+	return &CloudInfoClient{Cloudinfo: pic, timeout: timeout}
 }
 
 // GetProductDetails gets the available product details from the provider in the region
 func (ciCli *CloudInfoClient) GetProductDetails(provider string, service string, region string) ([]VirtualMachine, error) {
-	gpdp := products.NewGetProductsParams().WithRegion(region).WithProvider(provider).WithService(service)
+	gpdp := products.NewGetProductsParams().WithTimeout(ciCli.timeout).WithRegion(region).WithProvider(provider).WithService(service)
 
 	allProducts, err := ciCli.Products.GetProducts(gpdp)
 	if err != nil {
@@ -63,13 +107,14 @@ func (ciCli *CloudInfoClient) GetProductDetails(provider string, service string,
 
 	var vms []VirtualMachine
 
+	pricesUpdated := scrapingTime(allProducts.Payload.ScrapingTime)
 	for _, p := range allProducts.Payload.Products {
 		vms = append(vms, VirtualMachine{
 			Category:       p.Category,
 			Type:           p.Type,
 			OnDemandPrice:  p.OnDemandPrice,
 			AvgPrice:       avg(p.SpotPrice),
-			Cpus:           p.Cpus,
+			Cpus:           vcpus(provider, p.Cpus),
 			Mem:            p.Mem,
 			Gpus:           p.Gpus,
 			Burst:          p.Burst,
@@ -77,12 +122,37 @@ func (ciCli *CloudInfoClient) GetProductDetails(provider string, service string,
 			NetworkPerfCat: p.NtwPerfCat,
 			CurrentGen:     p.CurrentGen,
 			Zones:          p.Zones,
+			ZonePrices:     zonePrices(p.SpotPrice),
+			LocalStorage:   localStorage(p.Attributes[storageAttr]),
+			MaxIps:         ipCapacity(provider, p.Type),
+			Architecture:   architecture(provider, p.Type),
+			Ena:            enaSupport(provider, p.Type),
+			EbsOptimized:   ebsOptimized(provider, p.Type),
+			PricesUpdated:  pricesUpdated,
 		})
 	}
 
 	return vms, nil
 }
 
+// vcpus converts the cpus of an instance type reported by the Cloud Info service to vCPUs
+// the Oracle Cloud shapes are reported in OCPUs, so the cpu constraints of the requests mean the same on every provider
+func vcpus(provider string, cpus float64) float64 {
+	if provider == "oracle" {
+		return cpus * vcpusPerOcpu
+	}
+	return cpus
+}
+
+// scrapingTime parses the scraping time reported by the Cloud Info service (unix time in milliseconds), zero if unknown
+func scrapingTime(millis string) time.Time {
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
 func avg(prices []*models.ZonePrice) float64 {
 	if len(prices) == 0 {
 		return 0.0
@@ -94,9 +164,73 @@ func avg(prices []*models.ZonePrice) float64 {
 	return avgPrice / float64(len(prices))
 }
 
+// spotPriceStat computes the given statistic of the spot prices across the availability zones
+func spotPriceStat(zonePrices map[string]float64, stat string) float64 {
+	if len(zonePrices) == 0 {
+		return 0.0
+	}
+
+	prices := make([]float64, 0, len(zonePrices))
+	sum := 0.0
+	for _, price := range zonePrices {
+		prices = append(prices, price)
+		sum += price
+	}
+
+	switch stat {
+	case P50PriceStat:
+		return percentile(prices, 50)
+	case P90PriceStat:
+		return percentile(prices, 90)
+	case MaxPriceStat:
+		return percentile(prices, 100)
+	default:
+		return sum / float64(len(prices))
+	}
+}
+
+// percentile returns the p-th percentile of the values by the nearest-rank method
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	rank := int(math.Ceil(p / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	}
+	return values[rank-1]
+}
+
+// zonePrices collects the spot prices per availability zone
+func zonePrices(prices []*models.ZonePrice) map[string]float64 {
+	if len(prices) == 0 {
+		return nil
+	}
+	zonePrices := make(map[string]float64, len(prices))
+	for _, price := range prices {
+		zonePrices[price.Zone] = price.Price
+	}
+	return zonePrices
+}
+
+// localStorage parses the local storage capacity (GB) from the storage attribute, eg. "2 x 900 NVMe SSD"
+// instance types with no local storage (eg. "EBS only") have a capacity of 0
+func localStorage(storage string) float64 {
+	matches := storageRe.FindStringSubmatch(storage)
+	if matches == nil {
+		return 0
+	}
+	size, err := strconv.ParseFloat(matches[2], 64)
+	if err != nil {
+		return 0
+	}
+	if count, err := strconv.Atoi(matches[1]); err == nil {
+		return float64(count) * size
+	}
+	return size
+}
+
 // GetProvider validates provider
 func (ciCli *CloudInfoClient) GetProvider(prv string) (string, error) {
-	gpp := provider.NewGetProviderParams().WithProvider(prv)
+	gpp := provider.NewGetProviderParams().WithTimeout(ciCli.timeout).WithProvider(prv)
 
 	provider, err := ciCli.Provider.GetProvider(gpp)
 	if err != nil {
@@ -108,7 +242,7 @@ func (ciCli *CloudInfoClient) GetProvider(prv string) (string, error) {
 
 // GetService validates service
 func (ciCli *CloudInfoClient) GetService(prv string, svc string) (string, error) {
-	gsp := service.NewGetServiceParams().WithProvider(prv).WithService(svc)
+	gsp := service.NewGetServiceParams().WithTimeout(ciCli.timeout).WithProvider(prv).WithService(svc)
 
 	service, err := ciCli.Service.GetService(gsp)
 	if err != nil {
@@ -120,7 +254,7 @@ func (ciCli *CloudInfoClient) GetService(prv string, svc string) (string, error)
 
 // GetRegion validates region
 func (ciCli *CloudInfoClient) GetRegion(prv, svc, reg string) (string, error) {
-	grp := region.NewGetRegionParams().WithProvider(prv).WithService(svc).WithRegion(reg)
+	grp := region.NewGetRegionParams().WithTimeout(ciCli.timeout).WithProvider(prv).WithService(svc).WithRegion(reg)
 
 	r, err := ciCli.Region.GetRegion(grp)
 	if err != nil {
@@ -132,7 +266,7 @@ func (ciCli *CloudInfoClient) GetRegion(prv, svc, reg string) (string, error) {
 
 // GetRegions gets regions
 func (ciCli *CloudInfoClient) GetRegions(provider, service string) ([]*models.Continent, error) {
-	grp := regions.NewGetRegionsParams().WithProvider(provider).WithService(service)
+	grp := regions.NewGetRegionsParams().WithTimeout(ciCli.timeout).WithProvider(provider).WithService(service)
 	r, err := ciCli.Regions.GetRegions(grp)
 	if err != nil {
 		return nil, discriminateErrCtx(err)
@@ -142,7 +276,7 @@ func (ciCli *CloudInfoClient) GetRegions(provider, service string) ([]*models.Co
 
 // GetContinents gets continents
 func (ciCli *CloudInfoClient) GetContinents() (models.ContinentsResponse, error) {
-	gcp := continents.NewGetContinentsParams()
+	gcp := continents.NewGetContinentsParams().WithTimeout(ciCli.timeout)
 	c, err := ciCli.Continents.GetContinents(gcp)
 	if err != nil {
 		return nil, discriminateErrCtx(err)
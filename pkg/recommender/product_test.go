@@ -0,0 +1,475 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_zonePrices(t *testing.T) {
+	tests := []struct {
+		name   string
+		prices []*models.ZonePrice
+		check  func(zonePrices map[string]float64)
+	}{
+		{
+			name:   "no spot prices",
+			prices: nil,
+			check: func(zonePrices map[string]float64) {
+				assert.Nil(t, zonePrices)
+			},
+		},
+		{
+			name: "spot prices per zone",
+			prices: []*models.ZonePrice{
+				{Zone: "eu-west-1a", Price: 0.3},
+				{Zone: "eu-west-1b", Price: 0.2},
+			},
+			check: func(zonePrices map[string]float64) {
+				assert.Equal(t, map[string]float64{"eu-west-1a": 0.3, "eu-west-1b": 0.2}, zonePrices)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(zonePrices(test.prices))
+		})
+	}
+}
+
+func Test_scrapingTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		millis string
+		check  func(scraped time.Time)
+	}{
+		{
+			name:   "unix time in milliseconds",
+			millis: "1560168000000",
+			check: func(scraped time.Time) {
+				assert.True(t, time.Date(2019, 6, 10, 12, 0, 0, 0, time.UTC).Equal(scraped))
+			},
+		},
+		{
+			name:   "unknown scraping time",
+			millis: "",
+			check: func(scraped time.Time) {
+				assert.True(t, scraped.IsZero())
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(scrapingTime(test.millis))
+		})
+	}
+}
+
+func Test_spotPriceStat(t *testing.T) {
+	// 10 zones priced 0.1 ... 1.0
+	prices := make(map[string]float64)
+	for i := 1; i <= 10; i++ {
+		prices[fmt.Sprintf("zone-%d", i)] = float64(i) / 10
+	}
+	tests := []struct {
+		name   string
+		prices map[string]float64
+		stat   string
+		check  func(price float64)
+	}{
+		{
+			name:   "average by default",
+			prices: prices,
+			stat:   "",
+			check: func(price float64) {
+				assert.InDelta(t, 0.55, price, 1e-9)
+			},
+		},
+		{
+			name:   "average",
+			prices: prices,
+			stat:   AvgPriceStat,
+			check: func(price float64) {
+				assert.InDelta(t, 0.55, price, 1e-9)
+			},
+		},
+		{
+			name:   "median",
+			prices: prices,
+			stat:   P50PriceStat,
+			check: func(price float64) {
+				assert.Equal(t, 0.5, price)
+			},
+		},
+		{
+			name:   "90th percentile",
+			prices: prices,
+			stat:   P90PriceStat,
+			check: func(price float64) {
+				assert.Equal(t, 0.9, price)
+			},
+		},
+		{
+			name:   "90th percentile of a spiking price",
+			prices: map[string]float64{"a": 0.1, "b": 0.1, "c": 0.1, "d": 0.1, "e": 0.8},
+			stat:   P90PriceStat,
+			check: func(price float64) {
+				assert.Equal(t, 0.8, price)
+			},
+		},
+		{
+			name:   "maximum",
+			prices: prices,
+			stat:   MaxPriceStat,
+			check: func(price float64) {
+				assert.Equal(t, 1.0, price)
+			},
+		},
+		{
+			name:   "no prices",
+			prices: nil,
+			stat:   P90PriceStat,
+			check: func(price float64) {
+				assert.Equal(t, 0.0, price)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(spotPriceStat(test.prices, test.stat))
+		})
+	}
+}
+
+func TestVirtualMachine_CheapestZone(t *testing.T) {
+	vm := VirtualMachine{
+		ZonePrices: map[string]float64{"eu-west-1a": 0.3, "eu-west-1b": 0.2, "eu-west-1c": 0.2},
+	}
+	tests := []struct {
+		name  string
+		zones []string
+		check func(zone string)
+	}{
+		{
+			name: "cheapest of all zones, ties broken by name",
+			check: func(zone string) {
+				assert.Equal(t, "eu-west-1b", zone)
+			},
+		},
+		{
+			name:  "cheapest of the requested zones",
+			zones: []string{"eu-west-1a", "eu-west-1c"},
+			check: func(zone string) {
+				assert.Equal(t, "eu-west-1c", zone)
+			},
+		},
+		{
+			name:  "no price in the requested zones",
+			zones: []string{"eu-west-1d"},
+			check: func(zone string) {
+				assert.Equal(t, "", zone)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(vm.CheapestZone(test.zones))
+		})
+	}
+}
+
+func TestNodePool_PreferredZone(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodePool    NodePool
+		zones       []string
+		preferZones []string
+		check       func(zone string)
+	}{
+		{
+			name: "preferred zone chosen over a cheaper non-preferred zone",
+			nodePool: NodePool{
+				VmType:  VirtualMachine{ZonePrices: map[string]float64{"eu-west-1a": 0.3, "eu-west-1b": 0.1, "eu-west-1c": 0.2}},
+				VmClass: Spot,
+			},
+			preferZones: []string{"eu-west-1a", "eu-west-1c"},
+			check: func(zone string) {
+				assert.Equal(t, "eu-west-1c", zone)
+			},
+		},
+		{
+			name: "cheaper non-preferred zone chosen if not available in the preferred zones",
+			nodePool: NodePool{
+				VmType:  VirtualMachine{ZonePrices: map[string]float64{"eu-west-1b": 0.1, "eu-west-1c": 0.2}},
+				VmClass: Spot,
+			},
+			preferZones: []string{"eu-west-1a"},
+			check: func(zone string) {
+				assert.Equal(t, "eu-west-1b", zone)
+			},
+		},
+		{
+			name: "preferred zones outside the requested zones ignored",
+			nodePool: NodePool{
+				VmType:  VirtualMachine{ZonePrices: map[string]float64{"eu-west-1a": 0.3, "eu-west-1b": 0.1, "eu-west-1c": 0.2}},
+				VmClass: Spot,
+			},
+			zones:       []string{"eu-west-1b", "eu-west-1c"},
+			preferZones: []string{"eu-west-1a"},
+			check: func(zone string) {
+				assert.Equal(t, "eu-west-1b", zone)
+			},
+		},
+		{
+			name: "regular node pool placed in a preferred zone the instance type is available in",
+			nodePool: NodePool{
+				VmType:  VirtualMachine{Zones: []string{"eu-west-1a", "eu-west-1b"}, OnDemandPrice: 0.5},
+				VmClass: Regular,
+			},
+			preferZones: []string{"eu-west-1c", "eu-west-1b"},
+			check: func(zone string) {
+				assert.Equal(t, "eu-west-1b", zone)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(test.nodePool.PreferredZone(test.zones, test.preferZones))
+		})
+	}
+}
+
+func Test_localStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage string
+		check   func(size float64)
+	}{
+		{
+			name:    "multiple disks",
+			storage: "2 x 900 NVMe SSD",
+			check: func(size float64) {
+				assert.Equal(t, float64(1800), size)
+			},
+		},
+		{
+			name:    "single disk without count",
+			storage: "475 NVMe SSD",
+			check: func(size float64) {
+				assert.Equal(t, float64(475), size)
+			},
+		},
+		{
+			name:    "no local storage",
+			storage: "EBS only",
+			check: func(size float64) {
+				assert.Equal(t, float64(0), size)
+			},
+		},
+		{
+			name:    "missing attribute",
+			storage: "",
+			check: func(size float64) {
+				assert.Equal(t, float64(0), size)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(localStorage(test.storage))
+		})
+	}
+}
+
+func Test_ipCapacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		vmType   string
+		check    func(ips int)
+	}{
+		{
+			name:     "capacity by instance size",
+			provider: "amazon",
+			vmType:   "m5.4xlarge",
+			check: func(ips int) {
+				assert.Equal(t, 240, ips)
+			},
+		},
+		{
+			name:     "instance type deviating from its size",
+			provider: "amazon",
+			vmType:   "t3.large",
+			check: func(ips int) {
+				assert.Equal(t, 36, ips)
+			},
+		},
+		{
+			name:     "unknown size",
+			provider: "amazon",
+			vmType:   "m5.huge",
+			check: func(ips int) {
+				assert.Equal(t, 0, ips)
+			},
+		},
+		{
+			name:     "unknown for other providers",
+			provider: "google",
+			vmType:   "n1-standard-4",
+			check: func(ips int) {
+				assert.Equal(t, 0, ips)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(ipCapacity(test.provider, test.vmType))
+		})
+	}
+}
+
+func Test_instanceFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		vmType   string
+		check    func(ena, ebsOptimized bool)
+	}{
+		{
+			name:     "current generation",
+			provider: "amazon",
+			vmType:   "m5.xlarge",
+			check: func(ena, ebsOptimized bool) {
+				assert.True(t, ena)
+				assert.True(t, ebsOptimized)
+			},
+		},
+		{
+			name:     "older family without ena",
+			provider: "amazon",
+			vmType:   "m4.xlarge",
+			check: func(ena, ebsOptimized bool) {
+				assert.False(t, ena)
+				assert.True(t, ebsOptimized)
+			},
+		},
+		{
+			name:     "older family without either",
+			provider: "amazon",
+			vmType:   "t2.medium",
+			check: func(ena, ebsOptimized bool) {
+				assert.False(t, ena)
+				assert.False(t, ebsOptimized)
+			},
+		},
+		{
+			name:     "unknown for other providers",
+			provider: "google",
+			vmType:   "n1-standard-4",
+			check: func(ena, ebsOptimized bool) {
+				assert.False(t, ena)
+				assert.False(t, ebsOptimized)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(enaSupport(test.provider, test.vmType), ebsOptimized(test.provider, test.vmType))
+		})
+	}
+}
+
+func Test_architecture(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		vmType   string
+		check    func(arch string)
+	}{
+		{
+			name:     "graviton family",
+			provider: "amazon",
+			vmType:   "m6g.xlarge",
+			check: func(arch string) {
+				assert.Equal(t, ArmArchitecture, arch)
+			},
+		},
+		{
+			name:     "graviton family with suffix",
+			provider: "amazon",
+			vmType:   "c6gn.large",
+			check: func(arch string) {
+				assert.Equal(t, ArmArchitecture, arch)
+			},
+		},
+		{
+			name:     "intel family",
+			provider: "amazon",
+			vmType:   "g4dn.xlarge",
+			check: func(arch string) {
+				assert.Equal(t, X86Architecture, arch)
+			},
+		},
+		{
+			name:     "x86 for other providers",
+			provider: "google",
+			vmType:   "n1-standard-4",
+			check: func(arch string) {
+				assert.Equal(t, X86Architecture, arch)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(architecture(test.provider, test.vmType))
+		})
+	}
+}
+
+func Test_vcpus(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		cpus     float64
+		check    func(vcpus float64)
+	}{
+		{
+			name:     "oracle ocpus converted",
+			provider: "oracle",
+			cpus:     4,
+			check: func(vcpus float64) {
+				assert.Equal(t, 8.0, vcpus)
+			},
+		},
+		{
+			name:     "other providers report vcpus",
+			provider: "amazon",
+			cpus:     4,
+			check: func(vcpus float64) {
+				assert.Equal(t, 4.0, vcpus)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.check(vcpus(test.provider, test.cpus))
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package productcache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultTtl is the time the product details are cached for by default
+const DefaultTtl = 5 * time.Minute
+
+var cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "telescopes",
+	Name:      "product_cache_requests_total",
+	Help:      "Number of product details lookups in the cache by result (hit, miss or shared - waiting for a concurrent miss)",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(cacheRequests)
+}
+
+// entry holds the product details of a region along with the time they were retrieved
+type entry struct {
+	vms       []recommender.VirtualMachine
+	fetchedAt time.Time
+}
+
+// call is a retrieval of the product details of a region in progress, the concurrent misses of the region wait for its result
+// the retrieval is cancelled once all the callers waiting for it gave up
+type call struct {
+	done    chan struct{}
+	vms     []recommender.VirtualMachine
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// cachingSource is a CloudInfoSource caching the product details of the wrapped source for the given ttl
+// keyed by provider, service and region
+type cachingSource struct {
+	source recommender.CloudInfoSource
+	log    logur.Logger
+	ttl    time.Duration
+	now    func() time.Time
+
+	mux         sync.Mutex
+	entries     map[string]entry
+	inflight    map[string]*call
+	lastRefresh time.Time
+}
+
+func NewCachingSource(log logur.Logger, source recommender.CloudInfoSource, ttl time.Duration) *cachingSource {
+	return &cachingSource{
+		source:   source,
+		log:      log,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]entry),
+		inflight: make(map[string]*call),
+	}
+}
+
+// GetProductDetails returns the cached product details if they are not older than the ttl, retrieves them otherwise
+func (s *cachingSource) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	return s.GetProductDetailsContext(context.Background(), provider, service, region)
+}
+
+// GetProductDetailsContext returns the cached product details if they are not older than the ttl, retrieves them otherwise
+// the concurrent misses of a region share a single retrieval, the callers stop waiting for it when their context is done;
+// the callers get a copy of the vms, as the engine modifies them
+func (s *cachingSource) GetProductDetailsContext(ctx context.Context, provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	key := cacheKey(provider, service, region)
+
+	s.mux.Lock()
+	if e, ok := s.entries[key]; ok && s.now().Sub(e.fetchedAt) < s.ttl {
+		s.mux.Unlock()
+		cacheRequests.WithLabelValues("hit").Inc()
+		return copyVms(e.vms), nil
+	}
+	c, ok := s.inflight[key]
+	if ok {
+		cacheRequests.WithLabelValues("shared").Inc()
+	} else {
+		cacheRequests.WithLabelValues("miss").Inc()
+		retrievalCtx, cancel := context.WithCancel(context.Background())
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		s.inflight[key] = c
+		go s.retrieve(retrievalCtx, c, provider, service, region)
+	}
+	c.waiters++
+	s.mux.Unlock()
+
+	select {
+	case <-c.done:
+		if c.err != nil {
+			return nil, c.err
+		}
+		return copyVms(c.vms), nil
+	case <-ctx.Done():
+		s.mux.Lock()
+		if c.waiters--; c.waiters == 0 {
+			c.cancel()
+			// the next miss starts a new retrieval instead of joining the cancelled one
+			if s.inflight[key] == c {
+				delete(s.inflight, key)
+			}
+		}
+		s.mux.Unlock()
+		return nil, emperror.Wrap(ctx.Err(), "product details not retrieved")
+	}
+}
+
+// retrieve retrieves the product details of the region for the call and caches them
+func (s *cachingSource) retrieve(ctx context.Context, c *call, provider string, service string, region string) {
+	defer c.cancel()
+
+	c.vms, c.err = recommender.ProductDetails(ctx, s.source, provider, service, region)
+	if c.err == nil {
+		s.store(provider, service, region, c.vms)
+	}
+
+	key := cacheKey(provider, service, region)
+	s.mux.Lock()
+	if s.inflight[key] == c {
+		delete(s.inflight, key)
+	}
+	s.mux.Unlock()
+	close(c.done)
+}
+
+// store caches the product details of the region
+func (s *cachingSource) store(provider string, service string, region string, vms []recommender.VirtualMachine) {
+	key := cacheKey(provider, service, region)
+
+	s.mux.Lock()
+	s.entries[key] = entry{vms: vms, fetchedAt: s.now()}
+	s.mux.Unlock()
+	s.log.Debug("product details cached", map[string]interface{}{"key": key, "count": len(vms)})
+}
+
+// Generation returns the time the cached product details of the region were retrieved, it changes whenever they are
+// retrieved again; false if they are not cached or expired
+func (s *cachingSource) Generation(provider string, service string, region string) (time.Time, bool) {
+	s.mux.Lock()
+	e, ok := s.entries[cacheKey(provider, service, region)]
+	s.mux.Unlock()
+
+	if !ok || s.now().Sub(e.fetchedAt) >= s.ttl {
+		return time.Time{}, false
+	}
+	return e.fetchedAt, true
+}
+
+func cacheKey(provider string, service string, region string) string {
+	return strings.Join([]string{provider, service, region}, "/")
+}
+
+// GetRegions retrieves the regions from the wrapped source, they are not cached
+func (s *cachingSource) GetRegions(provider, service string) ([]*models.Continent, error) {
+	return s.source.GetRegions(provider, service)
+}
+
+func copyVms(vms []recommender.VirtualMachine) []recommender.VirtualMachine {
+	c := make([]recommender.VirtualMachine, len(vms))
+	copy(c, vms)
+	return c
+}
@@ -0,0 +1,237 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package productcache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/logur"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingSource struct {
+	calls int
+	err   error
+}
+
+func (s *countingSource) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []recommender.VirtualMachine{{Type: "m5.xlarge", AvgPrice: 0.07}}, nil
+}
+
+func (s *countingSource) GetRegions(provider, service string) ([]*models.Continent, error) {
+	return nil, nil
+}
+
+func TestCachingSource_GetProductDetails(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *countingSource
+		check  func(cache *cachingSource, source *countingSource, clock *time.Time)
+	}{
+		{
+			name:   "second request within the ttl served from the cache",
+			source: &countingSource{},
+			check: func(cache *cachingSource, source *countingSource, clock *time.Time) {
+				_, err := cache.GetProductDetails("amazon", "compute", "eu-west-1")
+				assert.Nil(t, err, "the error should be nil")
+				*clock = clock.Add(time.Minute)
+				vms, err := cache.GetProductDetails("amazon", "compute", "eu-west-1")
+				assert.Nil(t, err, "the error should be nil")
+
+				assert.Equal(t, 1, source.calls)
+				assert.Equal(t, "m5.xlarge", vms[0].Type)
+			},
+		},
+		{
+			name:   "expired entry retrieved again",
+			source: &countingSource{},
+			check: func(cache *cachingSource, source *countingSource, clock *time.Time) {
+				_, _ = cache.GetProductDetails("amazon", "compute", "eu-west-1")
+				*clock = clock.Add(DefaultTtl)
+				_, _ = cache.GetProductDetails("amazon", "compute", "eu-west-1")
+
+				assert.Equal(t, 2, source.calls)
+			},
+		},
+		{
+			name:   "regions cached separately",
+			source: &countingSource{},
+			check: func(cache *cachingSource, source *countingSource, clock *time.Time) {
+				_, _ = cache.GetProductDetails("amazon", "compute", "eu-west-1")
+				_, _ = cache.GetProductDetails("amazon", "compute", "us-east-1")
+
+				assert.Equal(t, 2, source.calls)
+			},
+		},
+		{
+			name:   "cached vms not modified by the callers",
+			source: &countingSource{},
+			check: func(cache *cachingSource, source *countingSource, clock *time.Time) {
+				vms, _ := cache.GetProductDetails("amazon", "compute", "eu-west-1")
+				vms[0].AvgPrice = 1
+				vms, _ = cache.GetProductDetails("amazon", "compute", "eu-west-1")
+
+				assert.Equal(t, 0.07, vms[0].AvgPrice)
+			},
+		},
+		{
+			name:   "generation changes when the entry is retrieved again",
+			source: &countingSource{},
+			check: func(cache *cachingSource, source *countingSource, clock *time.Time) {
+				_, ok := cache.Generation("amazon", "compute", "eu-west-1")
+				assert.False(t, ok)
+
+				_, _ = cache.GetProductDetails("amazon", "compute", "eu-west-1")
+				first, ok := cache.Generation("amazon", "compute", "eu-west-1")
+				assert.True(t, ok)
+
+				*clock = clock.Add(DefaultTtl)
+				_, ok = cache.Generation("amazon", "compute", "eu-west-1")
+				assert.False(t, ok, "expired entries have no generation")
+
+				_, _ = cache.GetProductDetails("amazon", "compute", "eu-west-1")
+				second, ok := cache.Generation("amazon", "compute", "eu-west-1")
+				assert.True(t, ok)
+				assert.True(t, second.After(first))
+			},
+		},
+		{
+			name:   "errors not cached",
+			source: &countingSource{err: errors.New("cloud info unavailable")},
+			check: func(cache *cachingSource, source *countingSource, clock *time.Time) {
+				_, err := cache.GetProductDetails("amazon", "compute", "eu-west-1")
+				assert.EqualError(t, err, "cloud info unavailable")
+				_, _ = cache.GetProductDetails("amazon", "compute", "eu-west-1")
+
+				assert.Equal(t, 2, source.calls)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+			cache := NewCachingSource(logur.NewTestLogger(), test.source, DefaultTtl)
+			cache.now = func() time.Time { return clock }
+
+			test.check(cache, test.source, &clock)
+		})
+	}
+}
+
+// blockingSource counts the retrievals, they block until the release channel is closed
+type blockingSource struct {
+	calls   int32
+	release chan struct{}
+}
+
+func (s *blockingSource) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	atomic.AddInt32(&s.calls, 1)
+	<-s.release
+	return []recommender.VirtualMachine{{Type: "m5.xlarge", AvgPrice: 0.07}}, nil
+}
+
+func (s *blockingSource) GetRegions(provider, service string) ([]*models.Continent, error) {
+	return nil, nil
+}
+
+func TestCachingSource_GetProductDetailsConcurrent(t *testing.T) {
+	source := &blockingSource{release: make(chan struct{})}
+	cache := NewCachingSource(logur.NewTestLogger(), source, DefaultTtl)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			vms, err := cache.GetProductDetails("amazon", "compute", "eu-west-1")
+			assert.Nil(t, err, "the error should be nil")
+			assert.Equal(t, "m5.xlarge", vms[0].Type)
+		}()
+	}
+
+	// wait for the first retrieval to start, the others wait for it or are served from the cache
+	for atomic.LoadInt32(&source.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(source.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&source.calls))
+}
+
+// contextSource counts the retrievals, they block until their context is done
+type contextSource struct {
+	calls     int32
+	cancelled chan struct{}
+}
+
+func (s *contextSource) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	return s.GetProductDetailsContext(context.Background(), provider, service, region)
+}
+
+func (s *contextSource) GetProductDetailsContext(ctx context.Context, provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	atomic.AddInt32(&s.calls, 1)
+	<-ctx.Done()
+	s.cancelled <- struct{}{}
+	return nil, ctx.Err()
+}
+
+func (s *contextSource) GetRegions(provider, service string) ([]*models.Continent, error) {
+	return nil, nil
+}
+
+func (s *contextSource) GetRegionsContext(ctx context.Context, provider, service string) ([]*models.Continent, error) {
+	return nil, nil
+}
+
+func TestCachingSource_GetProductDetailsContext(t *testing.T) {
+	source := &contextSource{cancelled: make(chan struct{})}
+	cache := NewCachingSource(logur.NewTestLogger(), source, DefaultTtl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for atomic.LoadInt32(&source.calls) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	vms, err := cache.GetProductDetailsContext(ctx, "amazon", "compute", "eu-west-1")
+	assert.EqualError(t, err, "product details not retrieved: context canceled")
+	assert.Nil(t, vms)
+
+	// the retrieval nobody waits for any more is cancelled
+	<-source.cancelled
+
+	// the next miss starts a new retrieval
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = cache.GetProductDetailsContext(ctx, "amazon", "compute", "eu-west-1")
+	assert.Error(t, err)
+	<-source.cancelled
+	assert.Equal(t, int32(2), atomic.LoadInt32(&source.calls))
+}
@@ -0,0 +1,84 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package productcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var lastRefresh = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "telescopes",
+	Name:      "product_cache_last_refresh_timestamp_seconds",
+	Help:      "Time of the last background refresh of the product details cache",
+})
+
+func init() {
+	prometheus.MustRegister(lastRefresh)
+}
+
+// Region identifies the product details of a region of a provider's service
+type Region struct {
+	Provider string
+	Service  string
+	Region   string
+}
+
+// Refresh retrieves the product details of the regions into the cache, the regions that fail are logged and skipped
+func (s *cachingSource) Refresh(regions []Region) {
+	for _, r := range regions {
+		vms, err := s.source.GetProductDetails(r.Provider, r.Service, r.Region)
+		if err != nil {
+			s.log.Warn("failed to refresh product details", map[string]interface{}{
+				"provider": r.Provider, "service": r.Service, "region": r.Region, "error": err.Error()})
+			continue
+		}
+		s.store(r.Provider, r.Service, r.Region, vms)
+	}
+
+	refreshed := s.now()
+	s.mux.Lock()
+	s.lastRefresh = refreshed
+	s.mux.Unlock()
+	lastRefresh.Set(float64(refreshed.Unix()))
+}
+
+// RefreshPeriodically refreshes the product details of the regions right away and then at every interval until the context is done
+// the interval should be shorter than the ttl for the recommendations to be served from the cache
+func (s *cachingSource) RefreshPeriodically(ctx context.Context, interval time.Duration, regions []Region) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.Refresh(regions)
+		s.log.Debug("product details refreshed", map[string]interface{}{"regions": len(regions)})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastRefresh returns the time of the last refresh, zero if the cache wasn't refreshed yet
+func (s *cachingSource) LastRefresh() time.Time {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.lastRefresh
+}
@@ -0,0 +1,49 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package productcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachingSource_Refresh(t *testing.T) {
+	clock := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	source := &countingSource{}
+	cache := NewCachingSource(logur.NewTestLogger(), source, DefaultTtl)
+	cache.now = func() time.Time { return clock }
+
+	assert.True(t, cache.LastRefresh().IsZero())
+
+	cache.Refresh([]Region{
+		{Provider: "amazon", Service: "compute", Region: "eu-west-1"},
+		{Provider: "amazon", Service: "compute", Region: "us-east-1"},
+	})
+	assert.Equal(t, 2, source.calls)
+	assert.Equal(t, clock, cache.LastRefresh())
+
+	clock = clock.Add(time.Minute)
+	vms, err := cache.GetProductDetails("amazon", "compute", "eu-west-1")
+	assert.Nil(t, err, "the error should be nil")
+	assert.Equal(t, "m5.xlarge", vms[0].Type)
+	_, _ = cache.GetProductDetails("amazon", "compute", "us-east-1")
+	assert.Equal(t, 2, source.calls, "the requests should be served from the refreshed cache")
+
+	_, _ = cache.GetProductDetails("amazon", "compute", "eu-central-1")
+	assert.Equal(t, 3, source.calls, "the regions not refreshed should be retrieved")
+}
@@ -0,0 +1,238 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providerhealth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/banzaicloud/telescopes/pkg/recommender/throttle"
+	"github.com/go-openapi/runtime"
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+	"github.com/pkg/errors"
+)
+
+const (
+	// UnavailableErrTag marks the errors of the requests to unhealthy providers
+	UnavailableErrTag = "unavailable"
+
+	// DefaultFailureThreshold is the number of consecutive upstream failures a provider is marked unhealthy after by default
+	DefaultFailureThreshold = 5
+	// DefaultCoolDown is the time the requests to an unhealthy provider fail fast for by default
+	DefaultCoolDown = time.Minute
+)
+
+// Status describes the health of a provider
+type Status struct {
+	// The cloud provider
+	Provider string `json:"provider"`
+	// Healthy signals that the requests to the provider are served
+	Healthy bool `json:"healthy"`
+	// Number of consecutive upstream failures
+	Failures int `json:"failures"`
+	// Time the requests to an unhealthy provider are tried again after
+	RetryAt *time.Time `json:"retryAt,omitempty"`
+}
+
+// state holds the consecutive upstream failures of a provider, the time it was marked unhealthy
+// and whether a request probing it after the cool-down is in flight
+type state struct {
+	failures    int
+	unhealthyAt time.Time
+	probing     bool
+}
+
+// Source is a CloudInfoSource tracking the health of the providers of the wrapped source like a circuit breaker:
+// a provider is marked unhealthy after the given number of consecutive upstream failures, the requests to it fail fast
+// until the cool-down passes, then the next request is let through as a probe (the others keep failing fast while it's
+// in flight) and either marks it healthy again or restarts the cool-down
+type Source struct {
+	source    recommender.CloudInfoSource
+	log       logur.Logger
+	threshold int
+	coolDown  time.Duration
+	now       func() time.Time
+
+	mux    sync.Mutex
+	states map[string]*state
+}
+
+func NewSource(log logur.Logger, source recommender.CloudInfoSource, threshold int, coolDown time.Duration) *Source {
+	return &Source{
+		source:    source,
+		log:       log,
+		threshold: threshold,
+		coolDown:  coolDown,
+		now:       time.Now,
+		states:    make(map[string]*state),
+	}
+}
+
+// Check returns an error if the provider is unhealthy and either its cool-down hasn't passed yet or it's being probed
+func (s *Source) Check(provider string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.check(provider)
+}
+
+func (s *Source) check(provider string) error {
+	st, ok := s.states[provider]
+	if !ok || st.failures < s.threshold {
+		return nil
+	}
+	if retryAt := st.unhealthyAt.Add(s.coolDown); s.now().Before(retryAt) {
+		return emperror.With(errors.Errorf("provider %s is unavailable after %d consecutive failures, retrying after %s",
+			provider, st.failures, retryAt.UTC().Format(time.RFC3339)), UnavailableErrTag)
+	}
+	if st.probing {
+		return emperror.With(errors.Errorf("provider %s is unavailable after %d consecutive failures, retrying",
+			provider, st.failures), UnavailableErrTag)
+	}
+	return nil
+}
+
+// admit checks whether the request to the provider is let through, it's the probe if the provider is unhealthy
+func (s *Source) admit(provider string) (bool, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if err := s.check(provider); err != nil {
+		return false, err
+	}
+	st, ok := s.states[provider]
+	if !ok || st.failures < s.threshold {
+		return false, nil
+	}
+	st.probing = true
+	return true, nil
+}
+
+// GetProductDetails retrieves the product details from the wrapped source unless the provider is unhealthy
+func (s *Source) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	return s.GetProductDetailsContext(context.Background(), provider, service, region)
+}
+
+// GetProductDetailsContext retrieves the product details from the wrapped source within the context unless the provider is unhealthy
+func (s *Source) GetProductDetailsContext(ctx context.Context, provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	probe, err := s.admit(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	vms, err := recommender.ProductDetails(ctx, s.source, provider, service, region)
+	s.record(provider, probe, err)
+	return vms, err
+}
+
+// GetRegions retrieves the regions from the wrapped source unless the provider is unhealthy
+func (s *Source) GetRegions(provider, service string) ([]*models.Continent, error) {
+	return s.GetRegionsContext(context.Background(), provider, service)
+}
+
+// GetRegionsContext retrieves the regions from the wrapped source within the context unless the provider is unhealthy
+func (s *Source) GetRegionsContext(ctx context.Context, provider, service string) ([]*models.Continent, error) {
+	probe, err := s.admit(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	continents, err := recommender.Regions(ctx, s.source, provider, service)
+	s.record(provider, probe, err)
+	return continents, err
+}
+
+// Statuses describes the health of the providers requested so far, ordered by provider
+func (s *Source) Statuses() []Status {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	statuses := make([]Status, 0, len(s.states))
+	for provider, st := range s.states {
+		status := Status{Provider: provider, Healthy: true, Failures: st.failures}
+		if st.failures >= s.threshold {
+			retryAt := st.unhealthyAt.Add(s.coolDown)
+			status.Healthy, status.RetryAt = !s.now().Before(retryAt), &retryAt
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Provider < statuses[j].Provider
+	})
+	return statuses
+}
+
+// record updates the health of the provider by the outcome of an upstream request
+func (s *Source) record(provider string, probe bool, err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	st, ok := s.states[provider]
+	if !ok {
+		st = &state{}
+		s.states[provider] = st
+	}
+	if probe {
+		st.probing = false
+	}
+	if throttled(err) {
+		// the request didn't reach the upstream, it tells nothing about its health
+		return
+	}
+
+	if !upstreamFailure(err) {
+		if st.failures >= s.threshold {
+			s.log.Info("provider is healthy again", map[string]interface{}{"provider": provider})
+		}
+		st.failures = 0
+		return
+	}
+
+	st.failures++
+	if st.failures >= s.threshold {
+		// restarts the cool-down if the request after the previous one failed
+		st.unhealthyAt = s.now()
+		s.log.Warn("provider marked unhealthy", map[string]interface{}{"provider": provider, "failures": st.failures})
+	}
+}
+
+// upstreamFailure checks whether the error signals an unreachable or failing upstream, not an invalid request
+func upstreamFailure(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case *url.Error:
+		return true
+	case *runtime.APIError:
+		return e.Code >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// throttled checks whether the request gave up waiting for a free slot of the throttle before reaching the upstream
+func throttled(err error) bool {
+	for _, v := range emperror.Context(err) {
+		if v == throttle.ThrottledErrTag {
+			return true
+		}
+	}
+	return false
+}
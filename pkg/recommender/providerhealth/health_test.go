@@ -0,0 +1,211 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providerhealth
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/banzaicloud/telescopes/pkg/recommender/throttle"
+	"github.com/go-openapi/runtime"
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakySource fails with the configured error and counts the calls
+type flakySource struct {
+	calls int
+	err   error
+}
+
+func (s *flakySource) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []recommender.VirtualMachine{{Type: "m5.xlarge"}}, nil
+}
+
+func (s *flakySource) GetRegions(provider, service string) ([]*models.Continent, error) {
+	s.calls++
+	return nil, s.err
+}
+
+// blockingSource holds the requests until released, it signals when a request entered
+type blockingSource struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSource) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	s.entered <- struct{}{}
+	<-s.release
+	return nil, nil
+}
+
+func (s *blockingSource) GetRegions(provider, service string) ([]*models.Continent, error) {
+	s.entered <- struct{}{}
+	<-s.release
+	return nil, nil
+}
+
+func TestSource_GetProductDetails(t *testing.T) {
+	unreachable := &url.Error{Op: "Get", URL: "http://cloudinfo", Err: http.ErrHandlerTimeout}
+	tests := []struct {
+		name  string
+		check func(health *Source, source *flakySource, clock *time.Time)
+	}{
+		{
+			name: "repeated failures mark the provider unhealthy",
+			check: func(health *Source, source *flakySource, clock *time.Time) {
+				source.err = unreachable
+				for i := 0; i < 3; i++ {
+					_, err := health.GetProductDetails("amazon", "compute", "eu-west-1")
+					assert.Equal(t, unreachable, err)
+				}
+
+				_, err := health.GetProductDetails("amazon", "compute", "eu-west-1")
+				assert.EqualError(t, err, "provider amazon is unavailable after 3 consecutive failures, retrying after 2019-06-10T12:01:00Z")
+				assert.Contains(t, emperror.Context(err), UnavailableErrTag)
+				assert.Equal(t, 3, source.calls, "the requests should fail fast")
+
+				assert.Equal(t, false, health.Statuses()[0].Healthy)
+				assert.NoError(t, health.Check("google"), "the other providers should be healthy")
+			},
+		},
+		{
+			name: "the provider recovers after the cool-down",
+			check: func(health *Source, source *flakySource, clock *time.Time) {
+				source.err = unreachable
+				for i := 0; i < 3; i++ {
+					_, _ = health.GetProductDetails("amazon", "compute", "eu-west-1")
+				}
+
+				*clock = clock.Add(time.Minute)
+				source.err = nil
+				vms, err := health.GetProductDetails("amazon", "compute", "eu-west-1")
+				assert.NoError(t, err)
+				assert.Equal(t, 1, len(vms))
+				assert.Equal(t, []Status{{Provider: "amazon", Healthy: true}}, health.Statuses())
+			},
+		},
+		{
+			name: "a failure after the cool-down restarts it",
+			check: func(health *Source, source *flakySource, clock *time.Time) {
+				source.err = unreachable
+				for i := 0; i < 3; i++ {
+					_, _ = health.GetProductDetails("amazon", "compute", "eu-west-1")
+				}
+
+				*clock = clock.Add(time.Minute)
+				_, err := health.GetProductDetails("amazon", "compute", "eu-west-1")
+				assert.Equal(t, unreachable, err)
+				assert.Error(t, health.Check("amazon"))
+				assert.Equal(t, 4, source.calls)
+			},
+		},
+		{
+			name: "a single probe is let through after the cool-down",
+			check: func(health *Source, source *flakySource, clock *time.Time) {
+				source.err = unreachable
+				for i := 0; i < 3; i++ {
+					_, _ = health.GetProductDetails("amazon", "compute", "eu-west-1")
+				}
+
+				*clock = clock.Add(time.Minute)
+				probe, err := health.admit("amazon")
+				assert.NoError(t, err)
+				assert.True(t, probe)
+
+				_, err = health.GetProductDetails("amazon", "compute", "eu-west-1")
+				assert.EqualError(t, err, "provider amazon is unavailable after 3 consecutive failures, retrying")
+				assert.Contains(t, emperror.Context(err), UnavailableErrTag)
+				assert.Error(t, health.Check("amazon"))
+				assert.Equal(t, 3, source.calls, "the requests should fail fast while the probe is in flight")
+
+				health.record("amazon", true, nil)
+				assert.NoError(t, health.Check("amazon"))
+			},
+		},
+		{
+			name: "a probe not getting a throttle slot leaves the provider unhealthy",
+			check: func(health *Source, source *flakySource, clock *time.Time) {
+				source.err = unreachable
+				for i := 0; i < 3; i++ {
+					_, _ = health.GetProductDetails("amazon", "compute", "eu-west-1")
+				}
+				source.err = nil
+
+				// the throttle between the health source and the upstream is saturated
+				blocking := &blockingSource{entered: make(chan struct{}), release: make(chan struct{})}
+				defer close(blocking.release)
+				throttled := throttle.NewSource(blocking, 1, time.Millisecond)
+				go func() { _, _ = throttled.GetRegions("amazon", "compute") }()
+				<-blocking.entered
+				health.source = throttled
+
+				*clock = clock.Add(time.Minute)
+				_, err := health.GetProductDetails("amazon", "compute", "eu-west-1")
+				assert.EqualError(t, err, "no free slot for the Cloud Info request: context deadline exceeded")
+				assert.Equal(t, 3, source.calls, "the probe shouldn't reach the upstream")
+
+				status := health.Statuses()[0]
+				assert.Equal(t, 3, status.Failures, "the failures shouldn't be reset")
+				assert.NoError(t, health.Check("amazon"), "the next request should probe the provider")
+			},
+		},
+		{
+			name: "invalid requests don't count as failures",
+			check: func(health *Source, source *flakySource, clock *time.Time) {
+				source.err = &runtime.APIError{Code: http.StatusNotFound}
+				for i := 0; i < 5; i++ {
+					_, _ = health.GetProductDetails("amazon", "compute", "unknown")
+				}
+				assert.NoError(t, health.Check("amazon"))
+				assert.Equal(t, 5, source.calls)
+			},
+		},
+		{
+			name: "a success resets the failures",
+			check: func(health *Source, source *flakySource, clock *time.Time) {
+				source.err = &runtime.APIError{Code: http.StatusBadGateway}
+				_, _ = health.GetProductDetails("amazon", "compute", "eu-west-1")
+				_, _ = health.GetProductDetails("amazon", "compute", "eu-west-1")
+				source.err = nil
+				_, _ = health.GetProductDetails("amazon", "compute", "eu-west-1")
+				source.err = &runtime.APIError{Code: http.StatusBadGateway}
+				_, _ = health.GetProductDetails("amazon", "compute", "eu-west-1")
+
+				assert.NoError(t, health.Check("amazon"))
+				assert.Equal(t, 1, health.Statuses()[0].Failures)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := &flakySource{}
+			clock := time.Date(2019, 6, 10, 12, 0, 0, 0, time.UTC)
+			health := NewSource(logur.NewTestLogger(), source, 3, time.Minute)
+			health.now = func() time.Time { return clock }
+
+			test.check(health, source, &clock)
+		})
+	}
+}
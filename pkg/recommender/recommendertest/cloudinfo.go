@@ -0,0 +1,56 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package recommendertest provides test doubles for testing against the recommendation engine.
+package recommendertest
+
+import (
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+)
+
+// FakeCloudInfoSource is a CloudInfoSource serving a fixed set of virtual machines, regardless of the provider, service and region
+type FakeCloudInfoSource struct {
+	// VirtualMachines served as the product details
+	VirtualMachines []recommender.VirtualMachine
+	// Zones set on the virtual machines that don't specify the zones they are available in
+	Zones []string
+	// Continents served as the regions
+	Continents []*models.Continent
+}
+
+// NewFakeCloudInfoSource creates a FakeCloudInfoSource serving the given virtual machines available in the given zones
+func NewFakeCloudInfoSource(vms []recommender.VirtualMachine, zones []string) *FakeCloudInfoSource {
+	return &FakeCloudInfoSource{
+		VirtualMachines: vms,
+		Zones:           zones,
+	}
+}
+
+// GetProductDetails returns a copy of the configured virtual machines, the engine may modify the returned ones
+func (s *FakeCloudInfoSource) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	vms := make([]recommender.VirtualMachine, len(s.VirtualMachines))
+	for i, vm := range s.VirtualMachines {
+		if len(vm.Zones) == 0 {
+			vm.Zones = s.Zones
+		}
+		vms[i] = vm
+	}
+	return vms, nil
+}
+
+// GetRegions returns the configured continents
+func (s *FakeCloudInfoSource) GetRegions(provider, service string) ([]*models.Continent, error) {
+	return s.Continents, nil
+}
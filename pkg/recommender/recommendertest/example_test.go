@@ -0,0 +1,55 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommendertest_test
+
+import (
+	"fmt"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/banzaicloud/telescopes/pkg/recommender/nodepools"
+	"github.com/banzaicloud/telescopes/pkg/recommender/recommendertest"
+	"github.com/banzaicloud/telescopes/pkg/recommender/vms"
+	"github.com/goph/logur"
+)
+
+func ExampleFakeCloudInfoSource() {
+	ciSource := recommendertest.NewFakeCloudInfoSource([]recommender.VirtualMachine{
+		{Type: "c5.xlarge", Cpus: 4, Mem: 8, OnDemandPrice: 0.192, CurrentGen: true},
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.214, CurrentGen: true},
+	}, []string{"eu-west-1a"})
+
+	log := logur.NewNoopLogger()
+	engine := recommender.NewEngine(log, ciSource, vms.NewVmSelector(log), nodepools.NewNodePoolSelector(log), nil, recommender.EngineConfig{})
+
+	resp, err := engine.RecommendCluster("amazon", "compute", "eu-west-1", recommender.ClusterRecommendationReq{
+		SumCpu:      8,
+		SumMem:      16,
+		MinNodes:    2,
+		MaxNodes:    2,
+		OnDemandPct: 100,
+	}, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, np := range resp.NodePools {
+		if np.SumNodes > 0 {
+			fmt.Printf("%s %s x%d\n", np.VmType.Type, np.VmClass, np.SumNodes)
+		}
+	}
+	// Output:
+	// c5.xlarge regular x2
+}
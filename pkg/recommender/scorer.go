@@ -0,0 +1,23 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+// PriceScorer scores the instance types by their spot price per cpu, the same as the cost objective ranks them by cpu
+type PriceScorer struct{}
+
+// Score returns the spot price per cpu of the vm
+func (PriceScorer) Score(vm VirtualMachine, req ClusterRecommendationReq) float64 {
+	return vm.AvgPrice / vm.Cpus
+}
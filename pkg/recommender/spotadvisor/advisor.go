@@ -0,0 +1,162 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spotadvisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+)
+
+const (
+	// DefaultUrl is the address of the public AWS Spot Instance Advisor dataset
+	DefaultUrl = "https://spot-bid-advisor.s3.amazonaws.com/spot-advisor-data.json"
+
+	// the dataset contains the ratings per operating system, the recommended instances run linux
+	operatingSystem = "Linux"
+
+	// the time the dataset isn't fetched again for after a failed fetch, the ratings are degraded until then
+	failureBackoff = time.Minute
+)
+
+// advisorData represents the part of the spot advisor dataset holding the interruption ranges
+// keyed by region, operating system and instance type
+type advisorData struct {
+	SpotAdvisor map[string]map[string]map[string]struct {
+		// index of the interruption frequency range, 0 is the lowest
+		Range int `json:"r"`
+	} `json:"spot_advisor"`
+}
+
+// fetchCall is a fetch of the dataset in progress, the concurrent callers without a cached dataset wait for its result
+type fetchCall struct {
+	done chan struct{}
+	data *advisorData
+	err  error
+}
+
+// spotAdvisor retrieves the interruption frequency ratings from the spot advisor dataset, the dataset is cached for the given ttl
+type spotAdvisor struct {
+	log    logur.Logger
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mux       sync.Mutex
+	data      *advisorData
+	fetchedAt time.Time
+	inflight  *fetchCall
+	failure   error
+	failedAt  time.Time
+}
+
+func NewSpotAdvisor(log logur.Logger, url string, ttl time.Duration) *spotAdvisor {
+	return &spotAdvisor{
+		log:    log,
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GetInterruptionRatings returns the interruption frequency ratings (1 - lowest, 5 - highest) of the instance types in the region
+// the dataset only covers amazon, no ratings are returned for other providers
+func (a *spotAdvisor) GetInterruptionRatings(provider, region string) (map[string]int, error) {
+	ratings := make(map[string]int)
+	if provider != "amazon" {
+		return ratings, nil
+	}
+
+	data, err := a.getData()
+	if err != nil {
+		return nil, err
+	}
+
+	for vmType, advice := range data.SpotAdvisor[region][operatingSystem] {
+		ratings[vmType] = advice.Range + 1
+	}
+	return ratings, nil
+}
+
+// getData returns the cached dataset, it's fetched again when expired; the expired dataset is used if it can't be refreshed
+// the dataset is fetched outside the lock by a single caller, the others use the expired dataset or wait for the fetch if there's none;
+// after a failed fetch the callers degrade immediately (expired dataset or the error) until the failure backoff passes
+func (a *spotAdvisor) getData() (*advisorData, error) {
+	a.mux.Lock()
+	data := a.data
+	if data != nil && time.Since(a.fetchedAt) < a.ttl {
+		a.mux.Unlock()
+		return data, nil
+	}
+	if failure := a.failure; failure != nil && time.Since(a.failedAt) < failureBackoff {
+		a.mux.Unlock()
+		if data != nil {
+			return data, nil
+		}
+		return nil, failure
+	}
+	if c := a.inflight; c != nil {
+		a.mux.Unlock()
+		if data != nil {
+			return data, nil
+		}
+		<-c.done
+		return c.data, c.err
+	}
+	c := &fetchCall{done: make(chan struct{})}
+	a.inflight = c
+	a.mux.Unlock()
+
+	c.data, c.err = a.fetch()
+
+	a.mux.Lock()
+	a.inflight = nil
+	if c.err != nil {
+		a.failure, a.failedAt = c.err, time.Now()
+		if a.data != nil {
+			a.log.Warn("failed to refresh the spot advisor data, using the cached one", map[string]interface{}{"error": c.err.Error()})
+			c.data, c.err = a.data, nil
+		}
+	} else {
+		a.data, a.fetchedAt, a.failure = c.data, time.Now(), nil
+	}
+	a.mux.Unlock()
+	close(c.done)
+
+	return c.data, c.err
+}
+
+func (a *spotAdvisor) fetch() (*advisorData, error) {
+	resp, err := a.client.Get(a.url)
+	if err != nil {
+		return nil, emperror.Wrap(err, "failed to retrieve the spot advisor data")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, emperror.With(fmt.Errorf("failed to retrieve the spot advisor data: %s", resp.Status), "url", a.url)
+	}
+
+	var data advisorData
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, emperror.Wrap(err, "failed to decode the spot advisor data")
+	}
+	return &data, nil
+}
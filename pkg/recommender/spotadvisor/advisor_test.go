@@ -0,0 +1,163 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spotadvisor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+const advisorDataset = `{
+  "ranges": [{"index": 0, "label": "<5%"}, {"index": 1, "label": "5-10%"}, {"index": 4, "label": ">20%"}],
+  "spot_advisor": {
+    "eu-west-1": {
+      "Linux": {"m5.xlarge": {"s": 70, "r": 0}, "c5.xlarge": {"s": 60, "r": 4}},
+      "Windows": {"m5.xlarge": {"s": 50, "r": 2}}
+    }
+  }
+}`
+
+func TestSpotAdvisor_GetInterruptionRatings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(advisorDataset))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		provider string
+		region   string
+		check    func(ratings map[string]int, err error)
+	}{
+		{
+			name:     "ratings of the linux instance types in the region",
+			url:      server.URL,
+			provider: "amazon",
+			region:   "eu-west-1",
+			check: func(ratings map[string]int, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, map[string]int{"m5.xlarge": 1, "c5.xlarge": 5}, ratings)
+			},
+		},
+		{
+			name:     "no ratings in an unknown region",
+			url:      server.URL,
+			provider: "amazon",
+			region:   "eu-north-1",
+			check: func(ratings map[string]int, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Empty(t, ratings)
+			},
+		},
+		{
+			name:     "no ratings for other providers",
+			url:      "http://localhost:0",
+			provider: "google",
+			region:   "europe-west1",
+			check: func(ratings map[string]int, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Empty(t, ratings)
+			},
+		},
+		{
+			name:     "unreachable dataset",
+			url:      "http://localhost:0",
+			provider: "amazon",
+			region:   "eu-west-1",
+			check: func(ratings map[string]int, err error) {
+				assert.NotNil(t, err, "the error should not be nil")
+				assert.Nil(t, ratings)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			advisor := NewSpotAdvisor(logur.NewTestLogger(), test.url, time.Hour)
+			test.check(advisor.GetInterruptionRatings(test.provider, test.region))
+		})
+	}
+}
+
+func TestSpotAdvisor_getDataCached(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(advisorDataset))
+	}))
+	defer server.Close()
+
+	advisor := NewSpotAdvisor(logur.NewTestLogger(), server.URL, time.Hour)
+	for i := 0; i < 3; i++ {
+		_, err := advisor.GetInterruptionRatings("amazon", "eu-west-1")
+		assert.Nil(t, err, "the error should be nil")
+	}
+	assert.Equal(t, 1, requests, "the dataset should be fetched once")
+}
+
+func TestSpotAdvisor_getDataSharedFetch(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(advisorDataset))
+	}))
+	defer server.Close()
+
+	advisor := NewSpotAdvisor(logur.NewTestLogger(), server.URL, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ratings, err := advisor.GetInterruptionRatings("amazon", "eu-west-1")
+			assert.Nil(t, err, "the error should be nil")
+			assert.Len(t, ratings, 2)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "the concurrent callers should share a single fetch")
+}
+
+func TestSpotAdvisor_getDataFailureBackoff(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	advisor := NewSpotAdvisor(logur.NewTestLogger(), server.URL, time.Hour)
+	for i := 0; i < 3; i++ {
+		ratings, err := advisor.GetInterruptionRatings("amazon", "eu-west-1")
+		assert.EqualError(t, err, "failed to retrieve the spot advisor data: 503 Service Unavailable")
+		assert.Nil(t, ratings)
+	}
+	assert.Equal(t, 1, requests, "the failed fetch shouldn't be retried during the backoff")
+
+	// the failure is retried after the backoff
+	advisor.failedAt = advisor.failedAt.Add(-failureBackoff)
+	_, _ = advisor.GetInterruptionRatings("amazon", "eu-west-1")
+	assert.Equal(t, 2, requests)
+}
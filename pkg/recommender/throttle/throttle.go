@@ -0,0 +1,104 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throttle
+
+import (
+	"context"
+	"time"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/emperror"
+	"github.com/pkg/errors"
+)
+
+const (
+	// UnavailableErrTag marks the errors of the requests that gave up waiting for a free slot
+	UnavailableErrTag = "unavailable"
+	// ThrottledErrTag tells these errors apart from the failures of the wrapped source, the request didn't reach it
+	ThrottledErrTag = "throttled"
+
+	// DefaultMaxConcurrency is the number of concurrent requests to the Cloud Info service allowed by default
+	DefaultMaxConcurrency = 16
+	// DefaultMaxWait is the time a request waits for a free slot by default
+	DefaultMaxWait = 10 * time.Second
+)
+
+// Source is a CloudInfoSource limiting the number of concurrent requests to the wrapped source
+// the requests over the limit wait until one of the requests in flight completes, at most for the max wait if set
+// and until the context of the caller is done if it's a ContextCloudInfoSource caller
+type Source struct {
+	source  recommender.CloudInfoSource
+	slots   chan struct{}
+	maxWait time.Duration
+}
+
+func NewSource(source recommender.CloudInfoSource, maxConcurrency int, maxWait time.Duration) *Source {
+	return &Source{
+		source:  source,
+		slots:   make(chan struct{}, maxConcurrency),
+		maxWait: maxWait,
+	}
+}
+
+// acquire takes a slot once one is free, it gives up without taking one when the context is done or the max wait passed
+func (s *Source) acquire(ctx context.Context) error {
+	if s.maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.maxWait)
+		defer cancel()
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return emperror.With(errors.Wrap(ctx.Err(), "no free slot for the Cloud Info request"), UnavailableErrTag, ThrottledErrTag)
+	}
+}
+
+func (s *Source) release() {
+	<-s.slots
+}
+
+// GetProductDetails retrieves the product details from the wrapped source once a slot is free
+func (s *Source) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	return s.GetProductDetailsContext(context.Background(), provider, service, region)
+}
+
+// GetProductDetailsContext retrieves the product details from the wrapped source once a slot is free, unless the context is done first
+func (s *Source) GetProductDetailsContext(ctx context.Context, provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
+	return recommender.ProductDetails(ctx, s.source, provider, service, region)
+}
+
+// GetRegions retrieves the regions from the wrapped source once a slot is free
+func (s *Source) GetRegions(provider, service string) ([]*models.Continent, error) {
+	return s.GetRegionsContext(context.Background(), provider, service)
+}
+
+// GetRegionsContext retrieves the regions from the wrapped source once a slot is free, unless the context is done first
+func (s *Source) GetRegionsContext(ctx context.Context, provider, service string) ([]*models.Continent, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
+	return recommender.Regions(ctx, s.source, provider, service)
+}
@@ -0,0 +1,142 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throttle
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/cloudinfo/pkg/cloudinfo-client/models"
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/emperror"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowSource tracks the number of requests in flight and the highest of it
+type slowSource struct {
+	inFlight int32
+	peak     int32
+}
+
+func (s *slowSource) call() {
+	n := atomic.AddInt32(&s.inFlight, 1)
+	for {
+		peak := atomic.LoadInt32(&s.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&s.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	atomic.AddInt32(&s.inFlight, -1)
+}
+
+func (s *slowSource) GetProductDetails(provider string, service string, region string) ([]recommender.VirtualMachine, error) {
+	s.call()
+	return []recommender.VirtualMachine{{Type: "m5.xlarge"}}, nil
+}
+
+func (s *slowSource) GetRegions(provider, service string) ([]*models.Continent, error) {
+	s.call()
+	return nil, nil
+}
+
+func TestSource_concurrency(t *testing.T) {
+	source := &slowSource{}
+	throttled := NewSource(source, 3, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				vms, err := throttled.GetProductDetails("amazon", "compute", "eu-west-1")
+				assert.Nil(t, err, "the error should be nil")
+				assert.Len(t, vms, 1)
+			} else {
+				_, err := throttled.GetRegions("amazon", "compute")
+				assert.Nil(t, err, "the error should be nil")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&source.peak))
+	assert.Zero(t, atomic.LoadInt32(&source.inFlight))
+}
+
+func TestSource_acquire(t *testing.T) {
+	throttled := NewSource(&slowSource{}, 1, 0)
+	assert.NoError(t, throttled.acquire(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := throttled.acquire(ctx)
+
+	assert.EqualError(t, err, "no free slot for the Cloud Info request: context canceled")
+	assert.Contains(t, emperror.Context(err), UnavailableErrTag)
+	assert.Len(t, throttled.slots, 1, "the cancelled caller shouldn't take a slot")
+
+	throttled.release()
+	assert.Empty(t, throttled.slots)
+}
+
+func TestSource_maxWait(t *testing.T) {
+	source := &slowSource{}
+	throttled := NewSource(source, 1, 5*time.Millisecond)
+	// the only slot is taken until the end of the test
+	assert.NoError(t, throttled.acquire(context.Background()))
+
+	_, err := throttled.GetProductDetails("amazon", "compute", "eu-west-1")
+
+	assert.EqualError(t, err, "no free slot for the Cloud Info request: context deadline exceeded")
+	assert.Contains(t, emperror.Context(err), UnavailableErrTag)
+	assert.Zero(t, atomic.LoadInt32(&source.peak), "the wrapped source shouldn't be called")
+	assert.Len(t, throttled.slots, 1)
+}
+
+func TestSource_GetProductDetailsContext(t *testing.T) {
+	source := &slowSource{}
+	throttled := NewSource(source, 1, time.Minute)
+	// the only slot is taken until both callers queue for it
+	assert.NoError(t, throttled.acquire(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := throttled.GetProductDetailsContext(ctx, "amazon", "compute", "eu-west-1")
+		cancelled <- err
+	}()
+	waiting := make(chan error)
+	go func() {
+		_, err := throttled.GetProductDetailsContext(context.Background(), "amazon", "compute", "eu-west-1")
+		waiting <- err
+	}()
+
+	// the caller given up on leaves the queue without waiting for the max wait
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	err := <-cancelled
+	assert.EqualError(t, err, "no free slot for the Cloud Info request: context canceled")
+	assert.Contains(t, emperror.Context(err), ThrottledErrTag)
+
+	throttled.release()
+	assert.NoError(t, <-waiting)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&source.peak), "only the waiting caller should reach the wrapped source")
+	assert.Empty(t, throttled.slots)
+}
@@ -0,0 +1,104 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import "fmt"
+
+// size tiers of the instance types
+const (
+	SmallTier  = "small"
+	MediumTier = "medium"
+	LargeTier  = "large"
+)
+
+// the largest number of cpus of the instance types in the small and the medium tiers
+const (
+	smallTierMaxCpus  = 4
+	mediumTierMaxCpus = 16
+)
+
+// tier returns the size tier of the vm by its cpus
+func tier(vm VirtualMachine) string {
+	switch {
+	case vm.Cpus <= smallTierMaxCpus:
+		return SmallTier
+	case vm.Cpus <= mediumTierMaxCpus:
+		return MediumTier
+	default:
+		return LargeTier
+	}
+}
+
+// groupByTier groups the vms by their size tier, the non-empty tiers are returned from the smallest to the largest
+func groupByTier(vms []VirtualMachine) ([]string, map[string][]VirtualMachine) {
+	groups := make(map[string][]VirtualMachine)
+	for _, vm := range vms {
+		groups[tier(vm)] = append(groups[tier(vm)], vm)
+	}
+
+	var tiers []string
+	for _, t := range []string{SmallTier, MediumTier, LargeTier} {
+		if len(groups[t]) > 0 {
+			tiers = append(tiers, t)
+		}
+	}
+
+	return tiers, groups
+}
+
+// recommendTiers splits the requested resources evenly across the size tiers of the products
+// and recommends the node pools of each tier independently from the instance types of the tier
+// the tiers without a recommendation are reported in the warnings, it fails only if none of the tiers has one
+func (e *Engine) recommendTiers(provider string, req ClusterRecommendationReq, allProducts []VirtualMachine) ([]NodePool, []string, error) {
+	tiers, groups := groupByTier(allProducts)
+	if len(tiers) < 2 {
+		nodePools, err := e.getCheapestNodePoolSet(provider, req, nil, allProducts)
+		return nodePools, nil, err
+	}
+
+	tierReq := req
+	tierReq.SumCpu = req.SumCpu / float64(len(tiers))
+	tierReq.SumMem = req.SumMem / float64(len(tiers))
+	tierReq.SumGpu = (req.SumGpu + len(tiers) - 1) / len(tiers)
+	tierReq.MinNodes = 1
+
+	var (
+		nodePools []NodePool
+		warnings  []string
+		firstErr  error
+	)
+	for _, t := range tiers {
+		nps, err := e.getCheapestNodePoolSet(provider, tierReq, nil, groups[t])
+		if err != nil {
+			e.log.Warn("failed to recommend node pools for tier", map[string]interface{}{"tier": t, "error": err.Error()})
+			warnings = append(warnings, fmt.Sprintf("no node pools for the %s tier: %s", t, err))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		for i := range nps {
+			nps[i].Tier = t
+		}
+		nodePools = append(nodePools, nps...)
+	}
+
+	if nodePools == nil {
+		return nil, nil, firstErr
+	}
+
+	return nodePools, warnings, nil
+}
@@ -0,0 +1,92 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"testing"
+
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_groupByTier(t *testing.T) {
+	tiers, groups := groupByTier([]VirtualMachine{
+		{Type: "m5.4xlarge", Cpus: 16},
+		{Type: "m5.large", Cpus: 2},
+		{Type: "m5.xlarge", Cpus: 4},
+	})
+
+	assert.Equal(t, []string{SmallTier, MediumTier}, tiers)
+	assert.Len(t, groups[SmallTier], 2)
+	assert.Equal(t, "m5.4xlarge", groups[MediumTier][0].Type)
+}
+
+func TestEngine_RecommendClusterTiered(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.large", Cpus: 2, Mem: 8, OnDemandPrice: 0.096},
+		{Type: "m5.2xlarge", Cpus: 8, Mem: 32, OnDemandPrice: 0.384},
+		{Type: "m5.8xlarge", Cpus: 32, Mem: 128, OnDemandPrice: 1.536},
+	}
+	tests := []struct {
+		name  string
+		req   ClusterRecommendationReq
+		check func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name: "mixed sizes by default",
+			req:  ClusterRecommendationReq{SumCpu: 96, SumMem: 384, MinNodes: 1, MaxNodes: 48, OnDemandPct: 100},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Len(t, resp.NodePools, 1)
+				assert.Empty(t, resp.NodePools[0].Tier)
+			},
+		},
+		{
+			name: "a node pool per tier",
+			req:  ClusterRecommendationReq{SumCpu: 96, SumMem: 384, MinNodes: 1, MaxNodes: 48, OnDemandPct: 100, Tiered: true},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				if assert.Len(t, resp.NodePools, 3) {
+					for i, expected := range []struct {
+						tier   string
+						vmType string
+						nodes  int
+					}{{SmallTier, "m5.large", 16}, {MediumTier, "m5.2xlarge", 4}, {LargeTier, "m5.8xlarge", 1}} {
+						assert.Equal(t, expected.tier, resp.NodePools[i].Tier)
+						assert.Equal(t, expected.vmType, resp.NodePools[i].VmType.Type)
+						assert.Equal(t, expected.nodes, resp.NodePools[i].SumNodes)
+					}
+				}
+				assert.Equal(t, 96.0, resp.Accuracy.RecCpu)
+			},
+		},
+		{
+			name: "tiers can't be requested by node count",
+			req:  ClusterRecommendationReq{NodeCount: 3, OnDemandPct: 100, Tiered: true},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, "tiered node pools can't be requested by nodeCount")
+				assert.Contains(t, emperror.Context(err), RecommenderErrorTag)
+				assert.Nil(t, resp)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &cheapestOnDemandPool{}, nil, EngineConfig{})
+			test.check(engine.RecommendCluster("dummy", "dummy", "dummy", test.req, nil))
+		})
+	}
+}
@@ -14,6 +14,13 @@
 
 package recommender
 
+import (
+	"context"
+	"time"
+
+	"github.com/goph/logur"
+)
+
 const (
 	// vm types - regular and ondemand means the same, they are both accepted on the API
 	Regular  = "regular"
@@ -28,7 +35,39 @@ const (
 	Master = "master"
 	Worker = "worker"
 
+	// recommendation objectives
+	Cost      = "cost"
+	Stability = "stability"
+	Balanced  = "balanced"
+	Carbon    = "carbon"
+
+	// statistics of the zone spot prices the spot instance types are priced by
+	AvgPriceStat = "avg"
+	P50PriceStat = "p50"
+	P90PriceStat = "p90"
+	MaxPriceStat = "max"
+
+	// workload types mapped to instance families
+	GeneralWorkload = "general"
+	ComputeWorkload = "compute"
+	MemoryWorkload  = "memory"
+	GpuWorkload     = "gpu"
+	StorageWorkload = "storage"
+
+	// capacity models the node pools are sized by
+	NodeCountCapacity    = "node-count"
+	VcpuWeightedCapacity = "vcpu-weighted"
+
+	// cpu architectures of the instance types
+	X86Architecture = "x86_64"
+	ArmArchitecture = "arm64"
+
+	// DefaultHoursPerMonth is the number of hours the monthly costs are estimated for by default
+	DefaultHoursPerMonth = 730
+
 	RecommenderErrorTag = "recommender"
+	// UnprocessableErrTag marks the errors of requests that are valid but can't be processed in the region
+	UnprocessableErrTag = "unprocessable"
 )
 
 // ClusterRecommender is the main entry point for cluster recommendation
@@ -42,37 +81,124 @@ type ClusterRecommender interface {
 
 	// RecommendMultiCluster performs recommendations
 	RecommendMultiCluster(req MultiClusterRecommendationReq) (map[string][]*ClusterRecommendationResp, error)
+
+	// ValidateClusterRecommendationReq checks the request against the rules of the recommendation without performing it
+	ValidateClusterRecommendationReq(req ClusterRecommendationReq) error
+
+	// Capabilities describes the request options supported on the known providers
+	Capabilities() []ProviderCapabilities
+
+	// WithLogger returns a recommender logging with the given logger, eg. to correlate the log lines of a request
+	WithLogger(log logur.Logger) ClusterRecommender
+
+	// WithContext returns a recommender retrieving the product details within the given context, eg. the deadline of a request
+	WithContext(ctx context.Context) ClusterRecommender
+}
+
+// InterruptionSource provides the spot interruption frequency ratings of the instance types
+type InterruptionSource interface {
+	// GetInterruptionRatings retrieves the ratings (1 - lowest, 5 - highest) keyed by instance type
+	GetInterruptionRatings(provider, region string) (map[string]int, error)
 }
 
 type VmRecommender interface {
 	RecommendVms(provider string, vms []VirtualMachine, attr string, req ClusterRecommendationReq, layout []NodePool) ([]VirtualMachine, []VirtualMachine, error)
 
 	FindVmsWithAttrValues(attr string, req ClusterRecommendationReq, layoutDesc []NodePoolDesc, allProducts []VirtualMachine) ([]VirtualMachine, error)
+
+	ExplainVms(provider string, attr string, req ClusterRecommendationReq, allProducts []VirtualMachine) ([]RejectedVm, error)
+
+	// Capabilities describes the request options supported on the known providers
+	Capabilities() []ProviderCapabilities
+}
+
+// ProviderCapabilities describes the request options supported on a provider, eg. to hide the irrelevant ones on a UI
+type ProviderCapabilities struct {
+	// The cloud provider
+	Provider string `json:"provider"`
+	// Spot or preemptible instance types are recommended
+	Spot bool `json:"spot"`
+	// GPU instance types can be requested
+	Gpu bool `json:"gpu"`
+	// Availability zones can be requested
+	Zones bool `json:"zones"`
+	// Network performance categories can be requested
+	NetworkPerf bool `json:"networkPerf"`
+	// Burst instance types can be excluded
+	Burst bool `json:"burst"`
+	// Older generation instance types can be allowed
+	OlderGen bool `json:"olderGen"`
+	// Minimum private ip addresses per node can be requested
+	MinIps bool `json:"minIps"`
+	// Elastic Network Adapter support and EBS optimization can be required
+	InstanceFeatures bool `json:"instanceFeatures"`
+	// Workload types that can be requested
+	WorkloadTypes []string `json:"workloadTypes"`
 }
 
 type NodePoolRecommender interface {
 	RecommendNodePools(attr string, req ClusterRecommendationReq, layout []NodePool, odVms []VirtualMachine, spotVms []VirtualMachine) []NodePool
 }
 
+// Scorer scores the instance types of the spot node pools, the lower scored ones are preferred
+type Scorer interface {
+	Score(vm VirtualMachine, req ClusterRecommendationReq) float64
+}
+
+// ScoringNodePoolRecommender is a NodePoolRecommender that ranks the spot instance types by a custom scorer if given one
+type ScoringNodePoolRecommender interface {
+	NodePoolRecommender
+	// WithScorer returns a copy of the recommender ranking the spot instance types by the scorer instead of the objective
+	WithScorer(scorer Scorer) NodePoolRecommender
+}
+
 // ClusterRecommendationReq encapsulates the recommendation input data
-// swagger:parameters recommendCluster
+// swagger:parameters recommendCluster validateClusterRecommendation
 type ClusterRecommendationReq struct {
 	// Total number of CPUs requested for the cluster
-	SumCpu float64 `json:"sumCpu" binding:"min=1"`
+	SumCpu float64 `json:"sumCpu" binding:"omitempty,min=1"`
 	// Total memory requested for the cluster (GB)
-	SumMem float64 `json:"sumMem" binding:"min=1"`
+	// it can also be given as a string with a unit suffix, eg. 16Gi, 32G or 65536Mi
+	SumMem float64 `json:"sumMem" binding:"omitempty,min=1"`
 	// Minimum number of nodes in the recommended cluster
-	MinNodes int `json:"minNodes,omitempty" binding:"min=1,ltefield=MaxNodes"`
+	MinNodes int `json:"minNodes,omitempty" binding:"omitempty,min=1,ltefield=MaxNodes"`
 	// Maximum number of nodes in the recommended cluster
 	MaxNodes int `json:"maxNodes,omitempty"`
+	// NodeCount is the number of nodes requested instead of the total cpus and memory, the nodes are of the
+	// cheapest instance type meeting the per node requirements
+	NodeCount int `json:"nodeCount,omitempty" binding:"min=0"`
+	// Minimum number of CPUs per node, 0 means no lower bound
+	MinVcpu float64 `json:"minVcpu,omitempty" binding:"min=0"`
+	// Maximum number of CPUs per node, 0 means no upper bound
+	MaxVcpu float64 `json:"maxVcpu,omitempty" binding:"min=0"`
+	// Minimum memory per node (GB), 0 means no lower bound
+	MinMem float64 `json:"minMem,omitempty" binding:"min=0"`
+	// Maximum memory per node (GB), 0 means no upper bound
+	MaxMem float64 `json:"maxMem,omitempty" binding:"min=0"`
+	// NodeSize is an abstract node size: small, medium, large, xlarge or <cpus>vcpu-<memory>gb, eg. 4vcpu-16gb
+	// it's resolved to per node cpu and memory bounds of the provider, the explicitly requested bounds take precedence
+	NodeSize string `json:"nodeSize,omitempty"`
+	// MaxOverprovisionPct caps the recommended cpus or memory (whichever the node pools are sized by) above the requested amount
+	// in percent, smaller instance types are recommended to fit within the cap; 0 means no cap
+	MaxOverprovisionPct float64 `json:"maxOverprovisionPct,omitempty" binding:"min=0"`
 	// If true, recommended instance types will have a similar size
 	SameSize bool `json:"sameSize,omitempty"`
 	// Percentage of regular (on-demand) nodes in the recommended cluster
 	OnDemandPct int `json:"onDemandPct,omitempty" binding:"min=0,max=100"`
+	// OnDemandOnly signals that only regular (on-demand) nodes are recommended, spot information is not used at all
+	// it overrides the on-demand percentage
+	OnDemandOnly bool `json:"onDemandOnly,omitempty"`
 	// Availability zones that the cluster should expand to
 	Zones []string `json:"zones,omitempty"`
+	// Availability zones preferred for the node pools, unlike the zones these don't exclude the other zones
+	// the node pools fall back to other zones if their instance type isn't available in the preferred ones
+	PreferZones []string `json:"preferZones,omitempty"`
+	// Minimum number of availability zones the recommended instance types must be available in
+	MinZones int `json:"minZones,omitempty" binding:"min=0"`
 	// Total number of GPUs requested for the cluster
 	SumGpu int `json:"sumGpu,omitempty"`
+	// AllowGpu signals whether GPU instance types are candidates even if no GPUs are requested
+	AllowGpu bool `json:"allowGpu,omitempty"`
 	// Are burst instances allowed in recommendation
 	AllowBurst *bool `json:"allowBurst,omitempty"`
 	// NetworkPerf specifies the network performance category
@@ -81,10 +207,68 @@ type ClusterRecommendationReq struct {
 	Excludes []string `json:"excludes,omitempty"`
 	// Includes is a whitelist - a slice with vm types to be contained in the recommendation
 	Includes []string `json:"includes,omitempty"`
+	// MinIps is the minimum number of private IP addresses per node, eg. for high pod density (applies for EC2 only)
+	MinIps int `json:"minIps,omitempty" binding:"min=0"`
+	// RequireEna signals that only instance types supporting the Elastic Network Adapter are recommended (applies for EC2 only)
+	RequireEna bool `json:"requireEna,omitempty"`
+	// RequireEbsOptimized signals that only instance types EBS-optimized by default are recommended (applies for EC2 only)
+	RequireEbsOptimized bool `json:"requireEbsOptimized,omitempty"`
 	// AllowOlderGen allow older generations of virtual machines (applies for EC2 only)
 	AllowOlderGen *bool `json:"allowOlderGen,omitempty"`
+	// CurrentGenOnly signals that only current generation instance types are recommended on every provider,
+	// it takes precedence over AllowOlderGen
+	CurrentGenOnly bool `json:"currentGenOnly,omitempty"`
 	// Category specifies the virtual machine category
 	Category []string `json:"category" binding:"omitempty,dive,category"`
+	// Explain signals that the response should contain the reasons why instance types were filtered out
+	Explain bool `json:"explain,omitempty"`
+	// IncludeRawPrices signals that the response should contain the per zone spot prices of the recommended instance types
+	// their spot price is computed from
+	IncludeRawPrices bool `json:"includeRawPrices,omitempty"`
+	// GroupByArchitecture signals that a separate recommendation is returned for each cpu architecture of the candidates
+	GroupByArchitecture bool `json:"groupByArchitecture,omitempty"`
+	// Tiered signals that the requested resources are split evenly across the size tiers (small, medium, large) of the candidates
+	// and the node pools of each tier are recommended independently from the instance types of the tier
+	Tiered bool `json:"tiered,omitempty"`
+	// PerZone signals that a spot node pool of the instance type with the cheapest spot price in the zone is recommended
+	// for each of the zones as well, eg. for zone specific node groups
+	PerZone bool `json:"perZone,omitempty"`
+	// MemPerCpu is the preferred memory (GB) per cpu ratio, instance types closest to it are recommended
+	MemPerCpu float64 `json:"memPerCpu,omitempty" binding:"min=0"`
+	// LocalStorage is the minimum local (instance store) storage per node (GB), 0 means any
+	LocalStorage float64 `json:"localStorage,omitempty" binding:"min=0"`
+	// MinInstanceTypes is the minimum number of distinct instance types the spot nodes are spread across
+	MinInstanceTypes int `json:"minInstanceTypes,omitempty" binding:"min=0"`
+	// OnDemandDiscounts are the committed discounts (percentage) of the on-demand prices keyed by instance family, eg. m5
+	// for reserved instances or savings plans; the discounted prices are used for ranking and reported in the response
+	OnDemandDiscounts map[string]float64 `json:"onDemandDiscounts,omitempty" binding:"omitempty,dive,min=0,max=100"`
+	// MinSpotDiscount is the minimum discount (percentage) of the spot price compared to the on-demand price
+	// instance types with a lower discount are not recommended for spot node pools
+	MinSpotDiscount float64 `json:"minSpotDiscount,omitempty" binding:"min=0,max=100"`
+	// SpotFallbackMultiplier prices the instance types without a spot price at their on-demand price multiplied by it
+	// for the spot node pools, so they can still be chosen if nothing better exists; they are left out if 0
+	SpotFallbackMultiplier float64 `json:"spotFallbackMultiplier,omitempty" binding:"omitempty,min=1"`
+	// MinPrice and MaxPrice bound the hourly price of the candidate instance types in the requested currency, 0 means no bound
+	// the band applies to the price the node pools are priced by: the on-demand price for regular, the spot price for spot node pools
+	MinPrice float64 `json:"minPrice,omitempty" binding:"min=0"`
+	MaxPrice float64 `json:"maxPrice,omitempty" binding:"min=0"`
+	// Objective the spot instance types are ranked by: cost (default), stability, balanced or carbon
+	// stability takes the spot interruption frequency ratings into account where available
+	// carbon ranks the instance types by cost, the multi-cluster recommendations by the carbon intensity of their regions
+	Objective string `json:"objective,omitempty" binding:"omitempty,eq=cost|eq=stability|eq=balanced|eq=carbon"`
+	// PriceStat is the statistic of the availability zone spot prices the spot instance types are priced by:
+	// avg (default), p50, p90 or max - the higher ones penalize the instance types with volatile spot prices across the zones
+	PriceStat string `json:"priceStat,omitempty" binding:"omitempty,eq=avg|eq=p50|eq=p90|eq=max"`
+	// Currency the prices are reported in (ISO 4217 code), USD by default
+	// other currencies are converted by the configured conversion rates
+	Currency string `json:"currency,omitempty" binding:"omitempty,len=3,alpha"`
+	// WorkloadType restricts the candidates to the instance families suited for the workload:
+	// general, compute, memory, gpu or storage
+	WorkloadType string `json:"workloadType,omitempty" binding:"omitempty,eq=general|eq=compute|eq=memory|eq=gpu|eq=storage"`
+	// CapacityModel the node pools are sized by: node-count (default) or vcpu-weighted
+	// vcpu-weighted sizes the pools the way spot fleets allocate a weighted capacity target: every node weighs its cpus
+	// and the requested cpus are spread evenly across the spot node pools
+	CapacityModel string `json:"capacityModel,omitempty" binding:"omitempty,eq=node-count|eq=vcpu-weighted"`
 }
 
 // MultiClusterRecommendationReq encapsulates the recommendation input data
@@ -110,6 +294,7 @@ type ClusterScaleoutRecommendationReq struct {
 	// Total desired number of CPUs in the cluster after the scale out
 	DesiredCpu float64 `json:"desiredCpu" binding:"min=1"`
 	// Total desired memory (GB) in the cluster after the scale out
+	// it can also be given as a string with a unit suffix, eg. 16Gi, 32G or 65536Mi
 	DesiredMem float64 `json:"desiredMem" binding:"min=1"`
 	// Total desired number of GPUs in the cluster after the scale out
 	DesiredGpu int `json:"desiredGpu" binding:"min=0"`
@@ -161,6 +346,42 @@ type ClusterRecommendationResp struct {
 	NodePools []NodePool `json:"nodePools"`
 	// Accuracy of the recommendation
 	Accuracy ClusterRecommendationAccuracy `json:"accuracy"`
+	// Currency of the prices in the recommendation
+	Currency string `json:"currency"`
+	// Relative carbon intensity of the electricity in the region, only present if the region is in the configured dataset
+	CarbonIntensity *float64 `json:"carbonIntensity,omitempty"`
+	// Degradations of the recommendation, eg. requested zones without spot prices
+	Warnings []string `json:"warnings,omitempty"`
+	// Human-readable summary of the recommended node pools, only present if requested
+	Summary string `json:"summary,omitempty"`
+	// Instance types filtered out during the recommendation, only present if explanation is requested
+	Explanation []RejectedVm `json:"explanation,omitempty"`
+	// Recommendations per cpu architecture, only present if requested and the candidates span more architectures
+	Architectures []ArchitectureRecommendation `json:"architectures,omitempty"`
+	// Spot node pools keyed by availability zone, each priced by the spot price in the zone, only present if requested
+	PerZone map[string]NodePool `json:"perZone,omitempty"`
+}
+
+// ArchitectureRecommendation is a recommendation restricted to the instance types of a single cpu architecture
+type ArchitectureRecommendation struct {
+	// Cpu architecture of the recommended instance types
+	Architecture string `json:"architecture"`
+	// Recommended node pools
+	NodePools []NodePool `json:"nodePools"`
+	// Accuracy of the recommendation
+	Accuracy ClusterRecommendationAccuracy `json:"accuracy"`
+	// Degradations of the recommendation
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// RejectedVm describes an instance type that was filtered out during the recommendation
+type RejectedVm struct {
+	// Instance type
+	Type string `json:"type"`
+	// The attribute the recommendation was performed for
+	Attribute string `json:"attribute"`
+	// Reasons why the instance type was rejected
+	Reasons []string `json:"reasons"`
 }
 
 // NodePool represents a set of instances with a specific vm type
@@ -173,6 +394,16 @@ type NodePool struct {
 	VmClass string `json:"vmClass"`
 	// Role in the cluster, eg. master or worker
 	Role string `json:"role"`
+	// Availability zone with the cheapest spot price, set for spot node pools only
+	CheapestZone string `json:"cheapestZone,omitempty"`
+	// Availability zone chosen for the node pool, set only if preferred zones are requested
+	Zone string `json:"zone,omitempty"`
+	// Estimated monthly cost of the node pool
+	MonthlyCost float64 `json:"monthlyCost"`
+	// Capacity units a node of the pool weighs (its cpus), set only for the vcpu-weighted capacity model
+	Weight float64 `json:"weight,omitempty"`
+	// Size tier of the instance type, set only if tiered node pools are requested
+	Tier string `json:"tier,omitempty"`
 }
 
 // PoolPrice calculates the price of the pool
@@ -187,6 +418,11 @@ func (n *NodePool) PoolPrice() float64 {
 	return sum
 }
 
+// monthlyCost estimates the monthly cost of a node pool from its hourly price
+func monthlyCost(poolPrice float64, hoursPerMonth float64) float64 {
+	return poolPrice * hoursPerMonth
+}
+
 // GetSum gets the total value for the given attribute per pool
 func (n NodePool) GetSum(attr string) float64 {
 	return float64(n.SumNodes) * n.VmType.GetAttrValue(attr)
@@ -224,6 +460,11 @@ type VirtualMachine struct {
 	AvgPrice float64 `json:"avgPrice"`
 	// Regular price of the instance type
 	OnDemandPrice float64 `json:"onDemandPrice"`
+	// On-demand and spot prices per cpu and per GB of memory, 0 if the instance type has no cpus or memory
+	OnDemandPricePerVcpu float64 `json:"onDemandPricePerVcpu,omitempty"`
+	OnDemandPricePerGb   float64 `json:"onDemandPricePerGb,omitempty"`
+	SpotPricePerVcpu     float64 `json:"spotPricePerVcpu,omitempty"`
+	SpotPricePerGb       float64 `json:"spotPricePerGb,omitempty"`
 	// Number of CPUs in the instance type
 	Cpus float64 `json:"cpusPerVm"`
 	// Available memory in the instance type (GB)
@@ -238,8 +479,82 @@ type VirtualMachine struct {
 	NetworkPerfCat string `json:"networkPerfCategory"`
 	// CurrentGen the vm is of current generation
 	CurrentGen bool `json:"currentGen"`
-	// Zones
+	// Availability zones the instance type is available in
 	Zones []string `json:"zones"`
+	// Local (instance store) storage capacity of the instance type (GB)
+	LocalStorage float64 `json:"localStorage"`
+	// Maximum number of private IP addresses of the instance type (amazon only), 0 if unknown
+	MaxIps int `json:"maxIps,omitempty"`
+	// Cpu architecture of the instance type, eg. x86_64 or arm64
+	Architecture string `json:"architecture,omitempty"`
+	// Ena signals the support of the Elastic Network Adapter (amazon only)
+	Ena bool `json:"ena,omitempty"`
+	// EbsOptimized signals that the instance type is EBS-optimized by default (amazon only)
+	EbsOptimized bool `json:"ebsOptimized,omitempty"`
+	// Spot interruption frequency rating from 1 (lowest) to 5 (highest), 0 if unknown
+	InterruptionRating int `json:"interruptionRating,omitempty"`
+	// Spot prices of the instance type per availability zone, only present in the recommendations if requested
+	ZonePrices map[string]float64 `json:"zonePrices,omitempty"`
+	// Time the prices of the instance type were last scraped by the Cloud Info service, zero if unknown
+	PricesUpdated time.Time `json:"-"`
+}
+
+// CheapestZone returns the availability zone with the lowest spot price among the given zones (all zones if empty)
+func (v *VirtualMachine) CheapestZone(zones []string) string {
+	return cheapestZone(v.ZonePrices, zones)
+}
+
+// PreferredZone returns the availability zone chosen for the node pool among the given zones (all zones if empty):
+// the cheapest of the preferred zones the instance type is available in, the cheapest of the other zones otherwise
+func (n *NodePool) PreferredZone(zones []string, preferZones []string) string {
+	prices := n.VmType.ZonePrices
+	if n.VmClass != Spot {
+		// the on-demand price is the same in all the zones the instance type is available in
+		prices = make(map[string]float64, len(n.VmType.Zones))
+		for _, zone := range n.VmType.Zones {
+			prices[zone] = n.VmType.OnDemandPrice
+		}
+	}
+
+	var preferred []string
+	for _, zone := range preferZones {
+		if len(zones) == 0 || contains(zones, zone) {
+			preferred = append(preferred, zone)
+		}
+	}
+	if len(preferred) > 0 {
+		if zone := cheapestZone(prices, preferred); zone != "" {
+			return zone
+		}
+	}
+	return cheapestZone(prices, zones)
+}
+
+// cheapestZone returns the zone with the lowest price among the given zones (all zones if empty), ties are broken by the zone name
+func cheapestZone(prices map[string]float64, zones []string) string {
+	var (
+		cheapest string
+		minPrice float64
+	)
+	for zone, price := range prices {
+		if len(zones) > 0 && !contains(zones, zone) {
+			continue
+		}
+		if cheapest == "" || price < minPrice || (price == minPrice && zone < cheapest) {
+			cheapest, minPrice = zone, price
+		}
+	}
+	return cheapest
+}
+
+// contains is a helper function to check if a slice contains a string
+func contains(slice []string, s string) bool {
+	for _, e := range slice {
+		if e == s {
+			return true
+		}
+	}
+	return false
 }
 
 func (v *VirtualMachine) GetAttrValue(attr string) float64 {
@@ -252,3 +567,13 @@ func (v *VirtualMachine) GetAttrValue(attr string) float64 {
 		return 0
 	}
 }
+
+// RegularPrice returns the price the regular node pools of the vm are priced by
+func (v VirtualMachine) RegularPrice() float64 {
+	return v.OnDemandPrice
+}
+
+// SpotPrice returns the price the spot node pools of the vm are priced by
+func (v VirtualMachine) SpotPrice() float64 {
+	return v.AvgPrice
+}
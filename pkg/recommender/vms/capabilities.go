@@ -0,0 +1,59 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vms
+
+import (
+	"sort"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+)
+
+// providerCapabilities holds the request options supported on the providers, see filtersForAttr for the provider specific filters
+var providerCapabilities = map[string]recommender.ProviderCapabilities{
+	"amazon":  {Spot: true, Gpu: true, Zones: true, NetworkPerf: true, Burst: true, OlderGen: true, MinIps: true, InstanceFeatures: true},
+	"google":  {Spot: true, Gpu: true, Zones: true, NetworkPerf: true},
+	"alibaba": {Spot: true, Gpu: true, Zones: true, NetworkPerf: true},
+	"azure":   {Gpu: true},
+	"oracle":  {Gpu: true},
+}
+
+// workloadTypes lists the workload types in the order they are reported
+var workloadTypes = []string{
+	recommender.GeneralWorkload,
+	recommender.ComputeWorkload,
+	recommender.MemoryWorkload,
+	recommender.GpuWorkload,
+	recommender.StorageWorkload,
+}
+
+// Capabilities describes the request options supported on the known providers, ordered by provider name
+func (s *vmSelector) Capabilities() []recommender.ProviderCapabilities {
+	capabilities := make([]recommender.ProviderCapabilities, 0, len(providerCapabilities))
+	for provider, c := range providerCapabilities {
+		c.Provider = provider
+		c.WorkloadTypes = make([]string, 0)
+		for _, workloadType := range workloadTypes {
+			if len(workloadFamilies[provider][workloadType]) > 0 {
+				c.WorkloadTypes = append(c.WorkloadTypes, workloadType)
+			}
+		}
+		capabilities = append(capabilities, c)
+	}
+
+	sort.Slice(capabilities, func(i, j int) bool {
+		return capabilities[i].Provider < capabilities[j].Provider
+	})
+	return capabilities
+}
@@ -0,0 +1,49 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vms
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVmSelector_Capabilities(t *testing.T) {
+	capabilities := NewVmSelector(logur.NewTestLogger()).Capabilities()
+
+	var providers []string
+	for _, c := range capabilities {
+		providers = append(providers, c.Provider)
+	}
+	assert.Equal(t, []string{"alibaba", "amazon", "azure", "google", "oracle"}, providers)
+
+	assert.Equal(t, recommender.ProviderCapabilities{
+		Provider:         "amazon",
+		Spot:             true,
+		Gpu:              true,
+		Zones:            true,
+		NetworkPerf:      true,
+		Burst:            true,
+		OlderGen:         true,
+		MinIps:           true,
+		InstanceFeatures: true,
+		WorkloadTypes:    []string{"general", "compute", "memory", "gpu", "storage"},
+	}, capabilities[1])
+
+	assert.False(t, capabilities[2].Spot, "spot instances should not be supported on azure")
+	assert.Equal(t, []string{"general", "compute", "memory"}, capabilities[3].WorkloadTypes)
+}
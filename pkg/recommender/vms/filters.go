@@ -15,58 +15,110 @@
 package vms
 
 import (
+	"math"
+
 	"github.com/banzaicloud/telescopes/pkg/recommender"
 	"github.com/goph/emperror"
 	"github.com/pkg/errors"
 )
 
-type vmFilter func(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool
+// vmFilter couples a filter function with the reason reported when the filter rejects a vm
+type vmFilter struct {
+	reason string
+	apply  func(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool
+}
 
 // filtersForAttr returns the slice for
 func (s *vmSelector) filtersForAttr(attr string, provider string, req recommender.ClusterRecommendationReq) ([]vmFilter, error) {
 	var filters []vmFilter
 	// generic filters - not depending on providers and attributes
 	if len(req.Includes) != 0 {
-		filters = append(filters, s.includesFilter)
+		filters = append(filters, vmFilter{"not in the includes list", s.includesFilter})
 	}
 
 	if len(req.Excludes) != 0 {
-		filters = append(filters, s.excludesFilter)
+		filters = append(filters, vmFilter{"in the excludes list", s.excludesFilter})
 	}
 
 	if len(req.Category) != 0 {
-		filters = append(filters, s.categoryFilter)
+		filters = append(filters, vmFilter{"category not requested", s.categoryFilter})
 	}
 
 	if len(req.Zones) != 0 {
-		filters = append(filters, s.zonesFilter)
+		filters = append(filters, vmFilter{"not available in the requested zones", s.zonesFilter})
+	}
+
+	if req.MinZones > 0 {
+		filters = append(filters, vmFilter{"not available in enough zones", s.minZonesFilter})
+	}
+
+	if req.MinVcpu > 0 || req.MaxVcpu > 0 {
+		filters = append(filters, vmFilter{"cpus out of the requested per node range", s.vcpuRangeFilter})
+	}
+
+	if req.MinMem > 0 || req.MaxMem > 0 {
+		filters = append(filters, vmFilter{"memory out of the requested per node range", s.memRangeFilter})
+	}
+
+	if req.CurrentGenOnly {
+		filters = append(filters, vmFilter{"older generation instances are not allowed", s.currentGenFilter})
+	}
+
+	if req.LocalStorage > 0 {
+		filters = append(filters, vmFilter{"not enough local storage", s.localStorageFilter})
+	}
+
+	if req.SumGpu == 0 && !req.AllowGpu && req.WorkloadType != recommender.GpuWorkload {
+		filters = append(filters, vmFilter{"gpu instances are not allowed if no gpus are requested", s.gpuFilter})
+	}
+
+	if req.WorkloadType != "" {
+		families := workloadFamilies[provider][req.WorkloadType]
+		if len(families) == 0 {
+			return nil, emperror.With(errors.New("no instance families are mapped to the workload type"),
+				recommender.RecommenderErrorTag, "workloadType", "provider", provider, "workloadType", req.WorkloadType)
+		}
+		filters = append(filters, vmFilter{"instance family not suited for the workload type", s.workloadTypeFilter(families)})
 	}
 
 	// provider specific filters
 	switch provider {
 	case "amazon":
 		if req.NetworkPerf != nil {
-			filters = append(filters, s.ntwPerformanceFilter)
+			filters = append(filters, vmFilter{"network performance category not requested", s.ntwPerformanceFilter})
 		}
 		// burst is not allowed
 		if req.AllowBurst != nil && !*req.AllowBurst {
-			filters = append(filters, s.burstFilter)
+			filters = append(filters, vmFilter{"burst instances are not allowed", s.burstFilter})
+		}
+		if !req.CurrentGenOnly && (req.AllowOlderGen == nil || !*req.AllowOlderGen) {
+			filters = append(filters, vmFilter{"older generation instances are not allowed", s.currentGenFilter})
 		}
-		if req.AllowOlderGen == nil || !*req.AllowOlderGen {
-			filters = append(filters, s.currentGenFilter)
+		if req.MinIps > 0 {
+			filters = append(filters, vmFilter{"not enough private ip addresses", s.minIpsFilter})
+		}
+		if req.RequireEna {
+			filters = append(filters, vmFilter{"no elastic network adapter support", s.enaFilter})
+		}
+		if req.RequireEbsOptimized {
+			filters = append(filters, vmFilter{"not ebs-optimized", s.ebsOptimizedFilter})
 		}
 	case "google", "alibaba":
 		if req.NetworkPerf != nil {
-			filters = append(filters, s.ntwPerformanceFilter)
+			filters = append(filters, vmFilter{"network performance category not requested", s.ntwPerformanceFilter})
 		}
 	}
 
-	// attribute specific filters
+	// attribute specific filters, there's no ratio to keep if nodes are requested by count
 	switch attr {
 	case recommender.Cpu:
-		filters = append(filters, s.minMemRatioFilter)
+		if req.NodeCount == 0 {
+			filters = append(filters, vmFilter{"memory/cpu ratio is lower than requested", s.minMemRatioFilter})
+		}
 	case recommender.Memory:
-		filters = append(filters, s.minCpuRatioFilter)
+		if req.NodeCount == 0 {
+			filters = append(filters, vmFilter{"cpu/memory ratio is lower than requested", s.minCpuRatioFilter})
+		}
 	default:
 		return nil, emperror.With(errors.New("unsupported attribute"), "attribute", attr)
 	}
@@ -78,7 +130,7 @@ func (s *vmSelector) filtersForAttr(attr string, provider string, req recommende
 // filtersApply returns true if all the filters apply for the given vm
 func (s *vmSelector) filtersApply(vm recommender.VirtualMachine, filters []vmFilter, req recommender.ClusterRecommendationReq) bool {
 	for _, filter := range filters {
-		if !filter(vm, req) {
+		if !filter.apply(vm, req) {
 			// one of the filters doesn't apply - quit the iteration
 			return false
 		}
@@ -87,6 +139,17 @@ func (s *vmSelector) filtersApply(vm recommender.VirtualMachine, filters []vmFil
 	return true
 }
 
+// rejectionReasons collects the reasons of all the filters that don't apply for the given vm
+func (s *vmSelector) rejectionReasons(vm recommender.VirtualMachine, filters []vmFilter, req recommender.ClusterRecommendationReq) []string {
+	var reasons []string
+	for _, filter := range filters {
+		if !filter.apply(vm, req) {
+			reasons = append(reasons, filter.reason)
+		}
+	}
+	return reasons
+}
+
 func (s *vmSelector) zonesFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
 	if len(vm.Zones) != 0 {
 		for _, zone := range req.Zones {
@@ -99,6 +162,47 @@ func (s *vmSelector) zonesFilter(vm recommender.VirtualMachine, req recommender.
 	return true
 }
 
+// minZonesFilter checks whether the vm is available in at least the requested number of zones
+// only the requested zones are counted if there are any in the request
+func (s *vmSelector) minZonesFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+	if len(req.Zones) == 0 {
+		return len(vm.Zones) >= req.MinZones
+	}
+	var zones int
+	for _, zone := range req.Zones {
+		if s.contains(vm.Zones, zone) {
+			zones++
+		}
+	}
+	return zones >= req.MinZones
+}
+
+// vcpuRangeFilter checks whether the number of cpus of the vm is between the requested per node bounds
+func (s *vmSelector) vcpuRangeFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+	if vm.Cpus < req.MinVcpu {
+		return false
+	}
+	return req.MaxVcpu == 0 || vm.Cpus <= req.MaxVcpu
+}
+
+// memRangeFilter checks whether the memory of the vm is between the requested per node bounds
+func (s *vmSelector) memRangeFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+	if vm.Mem < req.MinMem {
+		return false
+	}
+	return req.MaxMem == 0 || vm.Mem <= req.MaxMem
+}
+
+// localStorageFilter checks whether the vm has at least the requested local storage
+func (s *vmSelector) localStorageFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+	return vm.LocalStorage >= req.LocalStorage
+}
+
+// gpuFilter checks whether the vm has no gpus
+func (s *vmSelector) gpuFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+	return vm.Gpus == 0
+}
+
 func (s *vmSelector) minMemRatioFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
 	minMemToCpuRatio := req.SumMem / req.SumCpu
 	return minMemToCpuRatio <= vm.Mem/vm.Cpus
@@ -139,24 +243,104 @@ func (s *vmSelector) includesFilter(vm recommender.VirtualMachine, req recommend
 	return false
 }
 
+// filterOnDemands selects vm-s that potentially can be part of regular node pools
+// vm-s without an on-demand price (missing or stale product info) are left out instead of being considered free
+func (s *vmSelector) filterOnDemands(vms []recommender.VirtualMachine) []recommender.VirtualMachine {
+	fvms := make([]recommender.VirtualMachine, 0)
+	for _, vm := range vms {
+		if vm.OnDemandPrice > 0 {
+			fvms = append(fvms, vm)
+		}
+	}
+	return fvms
+}
+
 // filterSpots selects vm-s that potentially can be part of "spot" node pools
-func (s *vmSelector) filterSpots(vms []recommender.VirtualMachine) []recommender.VirtualMachine {
+// vm-s without a spot price or with a spot discount lower than the given percentage are left out
+func (s *vmSelector) filterSpots(vms []recommender.VirtualMachine, minDiscount float64) []recommender.VirtualMachine {
 	s.log.Debug("selecting spot instances for recommending spot pools")
 	fvms := make([]recommender.VirtualMachine, 0)
 	for _, vm := range vms {
-		if vm.AvgPrice != 0 {
+		if vm.AvgPrice != 0 && hasSpotDiscount(vm, minDiscount) {
+			fvms = append(fvms, vm)
+		}
+	}
+	return fvms
+}
+
+// filterPriceBand selects the vm-s with the given price within the requested price band
+func (s *vmSelector) filterPriceBand(vms []recommender.VirtualMachine, req recommender.ClusterRecommendationReq, price func(recommender.VirtualMachine) float64) []recommender.VirtualMachine {
+	if req.MinPrice == 0 && req.MaxPrice == 0 {
+		return vms
+	}
+
+	fvms := make([]recommender.VirtualMachine, 0)
+	for _, vm := range vms {
+		if inPriceBand(price(vm), req) {
+			fvms = append(fvms, vm)
+		}
+	}
+	return fvms
+}
+
+// inPriceBand checks whether the price is between the requested minimum and maximum price
+func inPriceBand(price float64, req recommender.ClusterRecommendationReq) bool {
+	if price < req.MinPrice {
+		return false
+	}
+	return req.MaxPrice == 0 || price <= req.MaxPrice
+}
+
+// hasSpotDiscount checks whether the spot price of the vm is at least the given percentage below its on-demand price
+func hasSpotDiscount(vm recommender.VirtualMachine, minDiscount float64) bool {
+	if minDiscount == 0 {
+		return true
+	}
+	if vm.OnDemandPrice == 0 {
+		return false
+	}
+	return (vm.OnDemandPrice-vm.AvgPrice)/vm.OnDemandPrice*100 >= minDiscount
+}
+
+// closestMemPerCpu selects the vm-s with the memory/cpu ratio closest to the given ratio
+func (s *vmSelector) closestMemPerCpu(vms []recommender.VirtualMachine, memPerCpu float64) []recommender.VirtualMachine {
+	minDist := math.MaxFloat64
+	for _, vm := range vms {
+		minDist = math.Min(minDist, math.Abs(vm.Mem/vm.Cpus-memPerCpu))
+	}
+
+	fvms := make([]recommender.VirtualMachine, 0)
+	for _, vm := range vms {
+		if math.Abs(vm.Mem/vm.Cpus-memPerCpu) == minDist {
 			fvms = append(fvms, vm)
 		}
 	}
+	s.log.Debug("selected vms closest to the memory/cpu ratio", map[string]interface{}{"memPerCpu": memPerCpu, "vmsCount": len(fvms)})
 	return fvms
 }
 
-// currentGenFilter removes instance types that are not the current generation (amazon only)
+// currentGenFilter removes instance types that are not the current generation
 func (s *vmSelector) currentGenFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
 	// filter by current generation
 	return vm.CurrentGen
 }
 
+// minIpsFilter checks whether the vm supports at least the requested number of private ip addresses
+// vms with unknown ip capacity are rejected
+func (s *vmSelector) minIpsFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+	return vm.MaxIps >= req.MinIps
+}
+
+// enaFilter checks whether the vm supports the elastic network adapter
+func (s *vmSelector) enaFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+	return vm.Ena
+}
+
+// ebsOptimizedFilter checks whether the vm is ebs-optimized by default
+func (s *vmSelector) ebsOptimizedFilter(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+	return vm.EbsOptimized
+}
+
 // contains is a helper function to check if a slice contains a string
 func (s *vmSelector) contains(slice []string, str string) bool {
 	for _, e := range slice {
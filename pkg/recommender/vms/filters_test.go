@@ -299,9 +299,10 @@ func TestVmSelector_includesFilter(t *testing.T) {
 
 func TestVmSelector_filterSpots(t *testing.T) {
 	tests := []struct {
-		name  string
-		vms   []recommender.VirtualMachine
-		check func(filtered []recommender.VirtualMachine)
+		name        string
+		vms         []recommender.VirtualMachine
+		minDiscount float64
+		check       func(filtered []recommender.VirtualMachine)
 	}{
 		{
 			name: "vm-s filtered out",
@@ -321,11 +322,34 @@ func TestVmSelector_filterSpots(t *testing.T) {
 				assert.Equal(t, 1, len(filtered), "vm is not filtered out")
 			},
 		},
+		{
+			name: "vm-s with a spot discount lower than the minimum filtered out",
+			vms: []recommender.VirtualMachine{
+				{
+					AvgPrice:      0.95,
+					OnDemandPrice: 1,
+					Type:          "t100",
+				},
+				{
+					AvgPrice:      0.5,
+					OnDemandPrice: 1,
+					Type:          "t200",
+				},
+				{
+					AvgPrice: 0.5,
+					Type:     "t300",
+				},
+			},
+			minDiscount: 20,
+			check: func(filtered []recommender.VirtualMachine) {
+				assert.Equal(t, []string{"t200"}, vmTypes(filtered))
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			selector := NewVmSelector(logur.NewTestLogger())
-			test.check(selector.filterSpots(test.vms))
+			test.check(selector.filterSpots(test.vms, test.minDiscount))
 		})
 	}
 }
@@ -411,3 +435,484 @@ func TestVmSelector_currentGenFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestVmSelector_closestMemPerCpu(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "c5.xlarge", Cpus: 4, Mem: 8},
+		{Type: "c5.2xlarge", Cpus: 8, Mem: 16},
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16},
+		{Type: "r5.xlarge", Cpus: 4, Mem: 32},
+	}
+	tests := []struct {
+		name      string
+		memPerCpu float64
+		check     func(vms []recommender.VirtualMachine)
+	}{
+		{
+			name:      "high memory per cpu ratio selects the r family",
+			memPerCpu: 8,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, 1, len(vms))
+				assert.Equal(t, "r5.xlarge", vms[0].Type)
+			},
+		},
+		{
+			name:      "low memory per cpu ratio selects the c family",
+			memPerCpu: 1.5,
+			check: func(vms []recommender.VirtualMachine) {
+				assert.Equal(t, 2, len(vms))
+				assert.Equal(t, "c5.xlarge", vms[0].Type)
+				assert.Equal(t, "c5.2xlarge", vms[1].Type)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			test.check(selector.closestMemPerCpu(vms, test.memPerCpu))
+		})
+	}
+}
+
+func TestVmSelector_minZonesFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		vm    recommender.VirtualMachine
+		req   recommender.ClusterRecommendationReq
+		check func(passed bool)
+	}{
+		{
+			name: "vm available in a single zone is rejected",
+			vm:   recommender.VirtualMachine{Type: "p3.16xlarge", Zones: []string{"eu-west-1a"}},
+			req:  recommender.ClusterRecommendationReq{MinZones: 2},
+			check: func(passed bool) {
+				assert.False(t, passed, "vm should not pass the filter")
+			},
+		},
+		{
+			name: "vm available in enough zones passes",
+			vm:   recommender.VirtualMachine{Type: "m5.xlarge", Zones: []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"}},
+			req:  recommender.ClusterRecommendationReq{MinZones: 2},
+			check: func(passed bool) {
+				assert.True(t, passed, "vm should pass the filter")
+			},
+		},
+		{
+			name: "only the requested zones are counted",
+			vm:   recommender.VirtualMachine{Type: "m5.xlarge", Zones: []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"}},
+			req:  recommender.ClusterRecommendationReq{MinZones: 2, Zones: []string{"eu-west-1a", "eu-west-1d"}},
+			check: func(passed bool) {
+				assert.False(t, passed, "vm should not pass the filter")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			test.check(selector.minZonesFilter(test.vm, test.req))
+		})
+	}
+}
+
+func TestVmSelector_localStorageFilter(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, LocalStorage: 0},
+		{Type: "c5d.xlarge", Cpus: 4, Mem: 16, LocalStorage: 100},
+		{Type: "i3.xlarge", Cpus: 4, Mem: 16, LocalStorage: 950},
+		{Type: "d2.xlarge", Cpus: 4, Mem: 16, LocalStorage: 6000},
+	}
+	tests := []struct {
+		name  string
+		req   recommender.ClusterRecommendationReq
+		check func(types []string)
+	}{
+		{
+			name: "any local storage",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 16, LocalStorage: 0},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.xlarge", "c5d.xlarge", "i3.xlarge", "d2.xlarge"}, types)
+			},
+		},
+		{
+			name: "positive local storage threshold",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 16, LocalStorage: 500},
+			check: func(types []string) {
+				assert.Equal(t, []string{"i3.xlarge", "d2.xlarge"}, types)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			filters, err := selector.filtersForAttr(recommender.Cpu, "dummy", test.req)
+			assert.Nil(t, err, "the error should be nil")
+
+			var types []string
+			for _, vm := range vms {
+				if selector.filtersApply(vm, filters, test.req) {
+					types = append(types, vm.Type)
+				}
+			}
+			test.check(types)
+		})
+	}
+}
+
+func TestVmSelector_gpuFilter(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16},
+		{Type: "c5.2xlarge", Cpus: 8, Mem: 16},
+		{Type: "p3.2xlarge", Cpus: 8, Mem: 61, Gpus: 1},
+		{Type: "g4dn.xlarge", Cpus: 4, Mem: 16, Gpus: 1},
+	}
+	tests := []struct {
+		name  string
+		req   recommender.ClusterRecommendationReq
+		check func(types []string)
+	}{
+		{
+			name: "gpu instance types excluded by default",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 8},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.xlarge", "c5.2xlarge"}, types)
+			},
+		},
+		{
+			name: "gpu instance types allowed",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 8, AllowGpu: true},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.xlarge", "c5.2xlarge", "p3.2xlarge", "g4dn.xlarge"}, types)
+			},
+		},
+		{
+			name: "gpu instance types not excluded if gpus are requested",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 8, SumGpu: 2},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.xlarge", "c5.2xlarge", "p3.2xlarge", "g4dn.xlarge"}, types)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			filters, err := selector.filtersForAttr(recommender.Cpu, "dummy", test.req)
+			assert.Nil(t, err, "the error should be nil")
+
+			var types []string
+			for _, vm := range vms {
+				if selector.filtersApply(vm, filters, test.req) {
+					types = append(types, vm.Type)
+				}
+			}
+			test.check(types)
+		})
+	}
+}
+
+func TestVmSelector_vcpuRangeFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		vm    recommender.VirtualMachine
+		req   recommender.ClusterRecommendationReq
+		check func(passed bool)
+	}{
+		{
+			name: "vm with less cpus than the minimum is rejected",
+			vm:   recommender.VirtualMachine{Cpus: 2},
+			req:  recommender.ClusterRecommendationReq{MinVcpu: 4},
+			check: func(passed bool) {
+				assert.False(t, passed, "vm should not pass the filter")
+			},
+		},
+		{
+			name: "vm with more cpus than the maximum is rejected",
+			vm:   recommender.VirtualMachine{Cpus: 16},
+			req:  recommender.ClusterRecommendationReq{MinVcpu: 4, MaxVcpu: 8},
+			check: func(passed bool) {
+				assert.False(t, passed, "vm should not pass the filter")
+			},
+		},
+		{
+			name: "vm without upper bound passes",
+			vm:   recommender.VirtualMachine{Cpus: 16},
+			req:  recommender.ClusterRecommendationReq{MinVcpu: 4},
+			check: func(passed bool) {
+				assert.True(t, passed, "vm should pass the filter")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			test.check(selector.vcpuRangeFilter(test.vm, test.req))
+		})
+	}
+}
+
+func TestVmSelector_memRangeFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		vm    recommender.VirtualMachine
+		req   recommender.ClusterRecommendationReq
+		check func(passed bool)
+	}{
+		{
+			name: "vm with less memory than the minimum is rejected",
+			vm:   recommender.VirtualMachine{Mem: 2},
+			req:  recommender.ClusterRecommendationReq{MinMem: 4},
+			check: func(passed bool) {
+				assert.False(t, passed, "vm should not pass the filter")
+			},
+		},
+		{
+			name: "vm with more memory than the maximum is rejected",
+			vm:   recommender.VirtualMachine{Mem: 64},
+			req:  recommender.ClusterRecommendationReq{MinMem: 4, MaxMem: 32},
+			check: func(passed bool) {
+				assert.False(t, passed, "vm should not pass the filter")
+			},
+		},
+		{
+			name: "vm within the bounds passes",
+			vm:   recommender.VirtualMachine{Mem: 16},
+			req:  recommender.ClusterRecommendationReq{MinMem: 4, MaxMem: 32},
+			check: func(passed bool) {
+				assert.True(t, passed, "vm should pass the filter")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			test.check(selector.memRangeFilter(test.vm, test.req))
+		})
+	}
+}
+
+func TestVmSelector_workloadTypeFilter(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, CurrentGen: true},
+		{Type: "t3.xlarge", Cpus: 4, Mem: 16, CurrentGen: true},
+		{Type: "c5.2xlarge", Cpus: 8, Mem: 16, CurrentGen: true},
+		{Type: "r5.xlarge", Cpus: 4, Mem: 32, CurrentGen: true},
+		{Type: "x1e.xlarge", Cpus: 4, Mem: 122, CurrentGen: true},
+		{Type: "p3.2xlarge", Cpus: 8, Mem: 61, Gpus: 1, CurrentGen: true},
+		{Type: "i3.xlarge", Cpus: 4, Mem: 30.5, CurrentGen: true},
+		{Type: "inf1.xlarge", Cpus: 4, Mem: 8, CurrentGen: true},
+	}
+	tests := []struct {
+		name     string
+		provider string
+		req      recommender.ClusterRecommendationReq
+		check    func(types []string, err error)
+	}{
+		{
+			name:     "general workload",
+			provider: "amazon",
+			req:      recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, WorkloadType: recommender.GeneralWorkload},
+			check: func(types []string, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"m5.xlarge", "t3.xlarge"}, types)
+			},
+		},
+		{
+			name:     "compute workload",
+			provider: "amazon",
+			req:      recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, WorkloadType: recommender.ComputeWorkload},
+			check: func(types []string, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"c5.2xlarge"}, types)
+			},
+		},
+		{
+			name:     "memory workload",
+			provider: "amazon",
+			req:      recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, WorkloadType: recommender.MemoryWorkload},
+			check: func(types []string, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"r5.xlarge", "x1e.xlarge"}, types)
+			},
+		},
+		{
+			name:     "gpu workload allows gpu instance types",
+			provider: "amazon",
+			req:      recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, WorkloadType: recommender.GpuWorkload},
+			check: func(types []string, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"p3.2xlarge"}, types)
+			},
+		},
+		{
+			name:     "storage workload",
+			provider: "amazon",
+			req:      recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, WorkloadType: recommender.StorageWorkload},
+			check: func(types []string, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"i3.xlarge"}, types)
+			},
+		},
+		{
+			name:     "workload type not mapped for the provider",
+			provider: "google",
+			req:      recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, WorkloadType: recommender.GpuWorkload},
+			check: func(types []string, err error) {
+				assert.EqualError(t, err, "no instance families are mapped to the workload type")
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			filters, err := selector.filtersForAttr(recommender.Cpu, test.provider, test.req)
+			if err != nil {
+				test.check(nil, err)
+				return
+			}
+
+			var types []string
+			for _, vm := range vms {
+				if selector.filtersApply(vm, filters, test.req) {
+					types = append(types, vm.Type)
+				}
+			}
+			test.check(types, nil)
+		})
+	}
+}
+
+func TestVmSelector_minIpsFilter(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "m5.large", Cpus: 2, Mem: 8, CurrentGen: true, MaxIps: 30},
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, CurrentGen: true, MaxIps: 60},
+		{Type: "m5.4xlarge", Cpus: 16, Mem: 64, CurrentGen: true, MaxIps: 240},
+		{Type: "m5.huge", Cpus: 16, Mem: 64, CurrentGen: true},
+	}
+	tests := []struct {
+		name  string
+		req   recommender.ClusterRecommendationReq
+		check func(types []string)
+	}{
+		{
+			name: "small instance types are excluded if many ips are requested",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, MinIps: 100},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.4xlarge"}, types)
+			},
+		},
+		{
+			name: "no ip constraint",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.large", "m5.xlarge", "m5.4xlarge", "m5.huge"}, types)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			filters, err := selector.filtersForAttr(recommender.Cpu, "amazon", test.req)
+			assert.Nil(t, err, "the error should be nil")
+
+			var types []string
+			for _, vm := range vms {
+				if selector.filtersApply(vm, filters, test.req) {
+					types = append(types, vm.Type)
+				}
+			}
+			test.check(types)
+		})
+	}
+}
+
+func TestVmSelector_currentGenOnly(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "m5.large", Cpus: 2, Mem: 8, CurrentGen: true},
+		{Type: "m4.large", Cpus: 2, Mem: 8, CurrentGen: false},
+	}
+	allowOlderGen := true
+	tests := []struct {
+		name  string
+		req   recommender.ClusterRecommendationReq
+		check func(types []string)
+	}{
+		{
+			name: "older generations allowed",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, AllowOlderGen: &allowOlderGen},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.large", "m4.large"}, types)
+			},
+		},
+		{
+			name: "current generation only takes precedence",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, AllowOlderGen: &allowOlderGen, CurrentGenOnly: true},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.large"}, types)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			filters, err := selector.filtersForAttr(recommender.Cpu, "amazon", test.req)
+			assert.Nil(t, err, "the error should be nil")
+
+			var types []string
+			for _, vm := range vms {
+				if selector.filtersApply(vm, filters, test.req) {
+					types = append(types, vm.Type)
+				}
+			}
+			test.check(types)
+		})
+	}
+}
+
+func TestVmSelector_instanceFeatureFilters(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, CurrentGen: true, Ena: true, EbsOptimized: true},
+		{Type: "m4.xlarge", Cpus: 4, Mem: 16, CurrentGen: true, EbsOptimized: true},
+		{Type: "c3.xlarge", Cpus: 4, Mem: 7.5, CurrentGen: true},
+	}
+	tests := []struct {
+		name  string
+		req   recommender.ClusterRecommendationReq
+		check func(types []string)
+	}{
+		{
+			name: "types without ena support are dropped",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, RequireEna: true},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.xlarge"}, types)
+			},
+		},
+		{
+			name: "types not ebs-optimized are dropped",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4, RequireEbsOptimized: true},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.xlarge", "m4.xlarge"}, types)
+			},
+		},
+		{
+			name: "no feature required",
+			req:  recommender.ClusterRecommendationReq{SumCpu: 4, SumMem: 4},
+			check: func(types []string) {
+				assert.Equal(t, []string{"m5.xlarge", "m4.xlarge", "c3.xlarge"}, types)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			filters, err := selector.filtersForAttr(recommender.Cpu, "amazon", test.req)
+			assert.Nil(t, err, "the error should be nil")
+
+			var types []string
+			for _, vm := range vms {
+				if selector.filtersApply(vm, filters, test.req) {
+					types = append(types, vm.Type)
+				}
+			}
+			test.check(types)
+		})
+	}
+}
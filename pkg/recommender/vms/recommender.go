@@ -16,6 +16,7 @@ package vms
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/banzaicloud/telescopes/pkg/recommender"
 	"github.com/goph/emperror"
@@ -54,6 +55,11 @@ func (s *vmSelector) RecommendVms(provider string, vms []recommender.VirtualMach
 		return []recommender.VirtualMachine{}, []recommender.VirtualMachine{}, nil
 	}
 
+	if req.MemPerCpu > 0 {
+		// prefer the instance types with a memory/cpu ratio closest to the requested one
+		filteredVms = s.closestMemPerCpu(filteredVms, req.MemPerCpu)
+	}
+
 	var odVms, spotVms []recommender.VirtualMachine
 	if layout == nil {
 		odVms, spotVms = filteredVms, filteredVms
@@ -72,9 +78,14 @@ func (s *vmSelector) RecommendVms(provider string, vms []recommender.VirtualMach
 		}
 	}
 
+	// retain only the nodes that have an on-demand price
+	odVms = s.filterOnDemands(odVms)
+	odVms = s.filterPriceBand(odVms, req, recommender.VirtualMachine.RegularPrice)
+
 	if req.OnDemandPct < 100 {
 		// retain only the nodes that are available as spot instances
-		spotVms = s.filterSpots(spotVms)
+		spotVms = s.filterSpots(spotVms, req.MinSpotDiscount)
+		spotVms = s.filterPriceBand(spotVms, req, recommender.VirtualMachine.SpotPrice)
 		if len(spotVms) == 0 {
 			s.log.Debug("no vms suitable for spot pools", map[string]interface{}{"attribute": attr})
 			return []recommender.VirtualMachine{}, []recommender.VirtualMachine{}, nil
@@ -89,6 +100,8 @@ func (s *vmSelector) FindVmsWithAttrValues(attr string, req recommender.ClusterR
 		vms    []recommender.VirtualMachine
 		values []float64
 		err    error
+		// instance types already added, a type is a candidate only once
+		seen = make(map[string]bool)
 	)
 
 	if layoutDesc == nil {
@@ -100,6 +113,9 @@ func (s *vmSelector) FindVmsWithAttrValues(attr string, req recommender.ClusterR
 	}
 
 	for _, p := range allProducts {
+		if seen[p.Type] {
+			continue
+		}
 		included := true
 		if len(values) > 0 {
 			included = false
@@ -120,6 +136,7 @@ func (s *vmSelector) FindVmsWithAttrValues(attr string, req recommender.ClusterR
 		}
 		if included {
 			vms = append(vms, p)
+			seen[p.Type] = true
 		}
 	}
 
@@ -127,6 +144,67 @@ func (s *vmSelector) FindVmsWithAttrValues(attr string, req recommender.ClusterR
 	return vms, nil
 }
 
+// ExplainVms collects the instance types that don't participate in the recommendation for the given attribute along with the reasons
+func (s *vmSelector) ExplainVms(provider string, attr string, req recommender.ClusterRecommendationReq, allProducts []recommender.VirtualMachine) ([]recommender.RejectedVm, error) {
+	values, err := s.recommendAttrValues(allProducts, attr, req)
+	if err != nil {
+		return nil, emperror.Wrap(err, "failed to recommend attribute values")
+	}
+
+	vmFilters, err := s.filtersForAttr(attr, provider, req)
+	if err != nil {
+		return nil, emperror.Wrap(err, "failed to identify filters")
+	}
+
+	rejected := make([]recommender.RejectedVm, 0)
+	for _, vm := range allProducts {
+		var reasons []string
+		if !containsValue(values, vm.GetAttrValue(attr)) {
+			reasons = append(reasons, fmt.Sprintf("%s value out of the recommended range %v", attr, values))
+		}
+
+		reasons = append(reasons, s.rejectionReasons(vm, vmFilters, req)...)
+
+		if req.OnDemandPct > 0 && vm.OnDemandPrice == 0 {
+			reasons = append(reasons, "no on-demand price available")
+		}
+
+		if req.OnDemandPct > 0 && vm.OnDemandPrice > 0 && !inPriceBand(vm.OnDemandPrice, req) {
+			reasons = append(reasons, "on-demand price out of the requested band")
+		}
+
+		if req.OnDemandPct < 100 && vm.AvgPrice > 0 && !inPriceBand(vm.AvgPrice, req) {
+			reasons = append(reasons, "spot price out of the requested band")
+		}
+
+		if req.OnDemandPct < 100 && vm.AvgPrice == 0 {
+			reasons = append(reasons, "no spot price available")
+		} else if req.OnDemandPct < 100 && !hasSpotDiscount(vm, req.MinSpotDiscount) {
+			reasons = append(reasons, "spot discount lower than requested")
+		}
+
+		if len(reasons) > 0 {
+			rejected = append(rejected, recommender.RejectedVm{
+				Type:      vm.Type,
+				Attribute: attr,
+				Reasons:   reasons,
+			})
+		}
+	}
+
+	return rejected, nil
+}
+
+// containsValue is a helper function to check if a slice contains a value
+func containsValue(values []float64, value float64) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // recommendAttrValues selects the attribute values allowed to participate in the recommendation process
 func (s *vmSelector) recommendAttrValues(allProducts []recommender.VirtualMachine, attr string, req recommender.ClusterRecommendationReq) ([]float64, error) {
 
@@ -158,8 +236,14 @@ func (s *vmSelector) recommendAttrValues(allProducts []recommender.VirtualMachin
 func maxValuePerVm(req recommender.ClusterRecommendationReq, attr string) float64 {
 	switch attr {
 	case recommender.Cpu:
+		if req.MaxVcpu > 0 {
+			return math.Min(req.SumCpu/float64(req.MinNodes), req.MaxVcpu)
+		}
 		return req.SumCpu / float64(req.MinNodes)
 	case recommender.Memory:
+		if req.MaxMem > 0 {
+			return math.Min(req.SumMem/float64(req.MinNodes), req.MaxMem)
+		}
 		return req.SumMem / float64(req.MinNodes)
 	default:
 		return 0
@@ -170,9 +254,9 @@ func maxValuePerVm(req recommender.ClusterRecommendationReq, attr string) float6
 func minValuePerVm(req recommender.ClusterRecommendationReq, attr string) float64 {
 	switch attr {
 	case recommender.Cpu:
-		return req.SumCpu / float64(req.MaxNodes)
+		return math.Max(req.SumCpu/float64(req.MaxNodes), req.MinVcpu)
 	case recommender.Memory:
-		return req.SumMem / float64(req.MaxNodes)
+		return math.Max(req.SumMem/float64(req.MaxNodes), req.MinMem)
 	default:
 		return 0
 	}
@@ -159,6 +159,150 @@ func TestVmSelector_RecommendVms(t *testing.T) {
 	}
 }
 
+func TestVmSelector_RecommendVmsBurst(t *testing.T) {
+	falseVal := false
+	vms := []recommender.VirtualMachine{
+		{Type: "t3.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.1664, Burst: true, CurrentGen: true},
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, CurrentGen: true},
+		{Type: "c5.xlarge", Cpus: 4, Mem: 8, OnDemandPrice: 0.17, CurrentGen: true},
+	}
+	tests := []struct {
+		name    string
+		request recommender.ClusterRecommendationReq
+		check   func([]recommender.VirtualMachine, []recommender.VirtualMachine, error)
+	}{
+		{
+			name: "burst instance types allowed by default",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes:    2,
+				MaxNodes:    2,
+				OnDemandPct: 100,
+				SumCpu:      8,
+				SumMem:      16,
+			},
+			check: func(odVms []recommender.VirtualMachine, spotVms []recommender.VirtualMachine, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"t3.xlarge", "m5.xlarge", "c5.xlarge"}, vmTypes(odVms))
+			},
+		},
+		{
+			name: "burst instance types excluded if not allowed",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes:    2,
+				MaxNodes:    2,
+				OnDemandPct: 100,
+				SumCpu:      8,
+				SumMem:      16,
+				AllowBurst:  &falseVal,
+			},
+			check: func(odVms []recommender.VirtualMachine, spotVms []recommender.VirtualMachine, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"m5.xlarge", "c5.xlarge"}, vmTypes(odVms))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			test.check(selector.RecommendVms("amazon", vms, recommender.Cpu, test.request, nil))
+		})
+	}
+}
+
+func TestVmSelector_RecommendVmsUnpriced(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.07, CurrentGen: true},
+		{Type: "m5a.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0, AvgPrice: 0.06, CurrentGen: true},
+	}
+	tests := []struct {
+		name    string
+		request recommender.ClusterRecommendationReq
+		check   func([]recommender.VirtualMachine, []recommender.VirtualMachine, error)
+	}{
+		{
+			name: "instance types without on-demand price are not recommended for regular node pools",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes:    2,
+				MaxNodes:    2,
+				OnDemandPct: 50,
+				SumCpu:      8,
+				SumMem:      16,
+			},
+			check: func(odVms []recommender.VirtualMachine, spotVms []recommender.VirtualMachine, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"m5.xlarge"}, vmTypes(odVms))
+				assert.Equal(t, []string{"m5.xlarge", "m5a.xlarge"}, vmTypes(spotVms))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			test.check(selector.RecommendVms("amazon", vms, recommender.Cpu, test.request, nil))
+		})
+	}
+}
+
+func TestVmSelector_RecommendVmsPriceBand(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{Type: "m5.large", Cpus: 2, Mem: 8, OnDemandPrice: 0.096, AvgPrice: 0.035, CurrentGen: true},
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.07, CurrentGen: true},
+		{Type: "m5.4xlarge", Cpus: 16, Mem: 64, OnDemandPrice: 0.768, AvgPrice: 0.28, CurrentGen: true},
+		{Type: "m5.12xlarge", Cpus: 48, Mem: 192, OnDemandPrice: 2.304, AvgPrice: 0.84, CurrentGen: true},
+	}
+	tests := []struct {
+		name    string
+		request recommender.ClusterRecommendationReq
+		check   func([]recommender.VirtualMachine, []recommender.VirtualMachine, error)
+	}{
+		{
+			name: "on-demand prices within the band",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes: 1, MaxNodes: 16, OnDemandPct: 100, SumCpu: 32, SumMem: 128, MinPrice: 0.1, MaxPrice: 1,
+			},
+			check: func(odVms []recommender.VirtualMachine, spotVms []recommender.VirtualMachine, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"m5.xlarge", "m5.4xlarge"}, vmTypes(odVms))
+			},
+		},
+		{
+			name: "spot prices within the band",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes: 1, MaxNodes: 16, OnDemandPct: 0, SumCpu: 32, SumMem: 128, MinPrice: 0.05, MaxPrice: 0.5,
+			},
+			check: func(odVms []recommender.VirtualMachine, spotVms []recommender.VirtualMachine, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"m5.xlarge", "m5.4xlarge"}, vmTypes(spotVms))
+			},
+		},
+		{
+			name: "no upper bound",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes: 1, MaxNodes: 16, OnDemandPct: 100, SumCpu: 32, SumMem: 128, MinPrice: 0.5,
+			},
+			check: func(odVms []recommender.VirtualMachine, spotVms []recommender.VirtualMachine, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"m5.4xlarge", "m5.12xlarge"}, vmTypes(odVms))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			test.check(selector.RecommendVms("amazon", vms, recommender.Cpu, test.request, nil))
+		})
+	}
+}
+
+// vmTypes collects the instance types of the vms
+func vmTypes(vms []recommender.VirtualMachine) []string {
+	var types []string
+	for _, vm := range vms {
+		types = append(types, vm.Type)
+	}
+	return types
+}
+
 func TestVmSelector_recommendAttrValues(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -183,6 +327,51 @@ func TestVmSelector_recommendAttrValues(t *testing.T) {
 
 			},
 		},
+		{
+			name: "few nodes force larger instances",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes: 1,
+				MaxNodes: 3,
+				SumMem:   100,
+				SumCpu:   100,
+			},
+			attribute: recommender.Cpu,
+			check: func(values []float64, err error) {
+				assert.Nil(t, err, "should not get error when recommending attributes")
+				assert.Equal(t, []float64{32}, values)
+			},
+		},
+		{
+			name: "max cpus per node force more, smaller instances",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes: 5,
+				MaxNodes: 20,
+				MaxVcpu:  8,
+				SumMem:   100,
+				SumCpu:   100,
+			},
+			attribute: recommender.Cpu,
+			check: func(values []float64, err error) {
+				assert.Nil(t, err, "should not get error when recommending attributes")
+				assert.Equal(t, []float64{8}, values)
+			},
+		},
+		{
+			name: "a cpu range per node expands to the available cpu units in the range",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes: 5,
+				MaxNodes: 50,
+				MinVcpu:  2,
+				MaxVcpu:  16,
+				SumMem:   100,
+				SumCpu:   100,
+			},
+			attribute: recommender.Cpu,
+			check: func(values []float64, err error) {
+				assert.Nil(t, err, "should not get error when recommending attributes")
+				assert.Equal(t, []float64{2, 4, 8, 16}, values)
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -191,3 +380,105 @@ func TestVmSelector_recommendAttrValues(t *testing.T) {
 		})
 	}
 }
+
+func TestVmSelector_ExplainVms(t *testing.T) {
+	vms := []recommender.VirtualMachine{
+		{
+			Type:          "m5.xlarge",
+			Cpus:          4,
+			Mem:           16,
+			OnDemandPrice: 0.192,
+			AvgPrice:      0.07,
+			CurrentGen:    true,
+		},
+		{
+			Type:          "m4.xlarge",
+			Cpus:          4,
+			Mem:           16,
+			OnDemandPrice: 0.2,
+			AvgPrice:      0.06,
+			CurrentGen:    true,
+		},
+	}
+	tests := []struct {
+		name      string
+		request   recommender.ClusterRecommendationReq
+		attribute string
+		check     func([]recommender.RejectedVm, error)
+	}{
+		{
+			name: "excluded vm type is explained",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes: 1,
+				MaxNodes: 4,
+				SumCpu:   8,
+				SumMem:   32,
+				Excludes: []string{"m4.xlarge"},
+			},
+			attribute: recommender.Cpu,
+			check: func(rejected []recommender.RejectedVm, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, 1, len(rejected))
+				assert.Equal(t, "m4.xlarge", rejected[0].Type)
+				assert.Equal(t, []string{"in the excludes list"}, rejected[0].Reasons)
+			},
+		},
+		{
+			name: "vm types passing the filters are not explained",
+			request: recommender.ClusterRecommendationReq{
+				MinNodes:    1,
+				MaxNodes:    4,
+				SumCpu:      8,
+				SumMem:      32,
+				OnDemandPct: 50,
+			},
+			attribute: recommender.Memory,
+			check: func(rejected []recommender.RejectedVm, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, 0, len(rejected))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			test.check(selector.ExplainVms("amazon", test.attribute, test.request, vms))
+		})
+	}
+}
+
+func TestVmSelector_FindVmsWithAttrValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		allProducts []recommender.VirtualMachine
+		request     recommender.ClusterRecommendationReq
+		check       func(vms []recommender.VirtualMachine, err error)
+	}{
+		{
+			name: "an instance type listed multiple times is a candidate once",
+			allProducts: []recommender.VirtualMachine{
+				{Type: "m5.xlarge", Cpus: 4, Mem: 16},
+				{Type: "c5.xlarge", Cpus: 4, Mem: 8},
+				{Type: "m5.xlarge", Cpus: 4, Mem: 16},
+			},
+			request: recommender.ClusterRecommendationReq{
+				MinNodes: 1,
+				MaxNodes: 2,
+				SumCpu:   8,
+				SumMem:   16,
+			},
+			check: func(vms []recommender.VirtualMachine, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, 2, len(vms))
+				assert.Equal(t, "m5.xlarge", vms[0].Type)
+				assert.Equal(t, "c5.xlarge", vms[1].Type)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := NewVmSelector(logur.NewTestLogger())
+			test.check(selector.FindVmsWithAttrValues(recommender.Cpu, test.request, nil, test.allProducts))
+		})
+	}
+}
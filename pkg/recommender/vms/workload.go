@@ -0,0 +1,69 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vms
+
+import (
+	"strings"
+
+	"github.com/banzaicloud/telescopes/pkg/recommender"
+)
+
+// workloadFamilies maps the workload types to the prefixes of the suitable instance families per provider
+// a workload type missing for a provider can't be requested on that provider
+var workloadFamilies = map[string]map[string][]string{
+	"amazon": {
+		recommender.GeneralWorkload: {"m", "t", "a1"},
+		recommender.ComputeWorkload: {"c"},
+		recommender.MemoryWorkload:  {"r", "x", "z"},
+		recommender.GpuWorkload:     {"p", "g"},
+		recommender.StorageWorkload: {"i2", "i3", "d2", "h1"},
+	},
+	"google": {
+		recommender.GeneralWorkload: {"n1-standard", "n2-standard", "n2d-standard", "e2-standard"},
+		recommender.ComputeWorkload: {"n1-highcpu", "n2-highcpu", "n2d-highcpu", "e2-highcpu", "c2-"},
+		recommender.MemoryWorkload:  {"n1-highmem", "n1-megamem", "n1-ultramem", "n2-highmem", "n2d-highmem", "e2-highmem", "m1-", "m2-"},
+	},
+	"azure": {
+		recommender.GeneralWorkload: {"Standard_A", "Standard_B", "Standard_D"},
+		recommender.ComputeWorkload: {"Standard_F"},
+		recommender.MemoryWorkload:  {"Standard_E", "Standard_G", "Standard_M"},
+		recommender.GpuWorkload:     {"Standard_N"},
+		recommender.StorageWorkload: {"Standard_L"},
+	},
+	"alibaba": {
+		recommender.GeneralWorkload: {"ecs.g5", "ecs.g6", "ecs.sn2", "ecs.t"},
+		recommender.ComputeWorkload: {"ecs.c", "ecs.sn1"},
+		recommender.MemoryWorkload:  {"ecs.r", "ecs.se1"},
+		recommender.GpuWorkload:     {"ecs.gn", "ecs.vgn"},
+		recommender.StorageWorkload: {"ecs.d1", "ecs.i"},
+	},
+	"oracle": {
+		recommender.GeneralWorkload: {"VM.Standard"},
+		recommender.GpuWorkload:     {"VM.GPU"},
+		recommender.StorageWorkload: {"VM.DenseIO"},
+	},
+}
+
+// workloadTypeFilter creates a filter that passes the vms belonging to one of the given instance families
+func (s *vmSelector) workloadTypeFilter(families []string) func(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+	return func(vm recommender.VirtualMachine, req recommender.ClusterRecommendationReq) bool {
+		for _, family := range families {
+			if strings.HasPrefix(vm.Type, family) {
+				return true
+			}
+		}
+		return false
+	}
+}
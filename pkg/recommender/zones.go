@@ -0,0 +1,154 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+
+	"github.com/goph/emperror"
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxZones is the number of distinct zones a request can list by default
+const DefaultMaxZones = 10
+
+// zoneRe matches the availability zone names of the providers, eg. eu-west-1a, europe-west1-b or 1
+var zoneRe = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,62}[A-Za-z0-9])?$`)
+
+// dedupeZones removes the repeated zones keeping the first occurrences in order
+func dedupeZones(zones []string) []string {
+	if len(zones) == 0 {
+		return zones
+	}
+
+	seen := make(map[string]bool, len(zones))
+	deduped := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		if !seen[zone] {
+			seen[zone] = true
+			deduped = append(deduped, zone)
+		}
+	}
+	return deduped
+}
+
+// checkZones checks that the zones and the preferred zones of the request are valid zone names and there are not too many of them
+func checkZones(req ClusterRecommendationReq, maxZones int) error {
+	for _, zones := range [][]string{req.Zones, req.PreferZones} {
+		for _, zone := range zones {
+			if !zoneRe.MatchString(zone) {
+				return errors.Errorf("invalid zone: %q", zone)
+			}
+		}
+		if n := len(dedupeZones(zones)); n > maxZones {
+			return errors.Errorf("at most %d zones can be requested, got %d", maxZones, n)
+		}
+	}
+	return nil
+}
+
+// pricedZones returns the zones any of the vms has a spot price in, in alphabetical order
+func pricedZones(vms []VirtualMachine) []string {
+	seen := make(map[string]bool)
+	var zones []string
+	for _, vm := range vms {
+		for zone := range vm.ZonePrices {
+			if !seen[zone] {
+				seen[zone] = true
+				zones = append(zones, zone)
+			}
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// zoneNodes returns the number of nodes of the vm providing an even share of the requested resources in one of the zones
+func zoneNodes(req ClusterRecommendationReq, zones int, vm VirtualMachine) int {
+	if req.NodeCount > 0 {
+		return int(math.Ceil(float64(req.NodeCount) / float64(zones)))
+	}
+
+	nodes := math.Max(math.Ceil(req.SumCpu/float64(zones)/vm.Cpus), math.Ceil(req.SumMem/float64(zones)/vm.Mem))
+	return int(math.Max(nodes, 1))
+}
+
+// recommendPerZone recommends a spot node pool for each of the zones (all zones with spot prices if none requested)
+// from the instance type with the cheapest spot price in the zone, sized for an even share of the requested resources
+// the zones without a recommendation are reported in the warnings
+func (e *Engine) recommendPerZone(provider string, req ClusterRecommendationReq, allProducts []VirtualMachine) (map[string]NodePool, []string, error) {
+	zones := req.Zones
+	if len(zones) == 0 {
+		zones = pricedZones(allProducts)
+	}
+	if len(zones) == 0 {
+		return nil, []string{"no spot prices available per zone, no zone node pools are recommended"}, nil
+	}
+
+	var warnings []string
+	nodePools := make(map[string]NodePool, len(zones))
+	for _, zone := range zones {
+		zoneReq := req
+		zoneReq.Zones = []string{zone}
+		zoneReq.OnDemandPct = 0
+
+		_, spotVms, err := e.vmSelector.RecommendVms(provider, allProducts, Cpu, zoneReq, nil)
+		if err != nil {
+			return nil, nil, emperror.Wrap(err, "failed to recommend virtual machines")
+		}
+
+		var (
+			cheapest      *NodePool
+			cheapestPrice float64
+		)
+		for _, vm := range spotVms {
+			zonePrice, ok := vm.ZonePrices[zone]
+			if !ok || zonePrice <= 0 {
+				continue
+			}
+
+			vm.AvgPrice = zonePrice
+			np := NodePool{VmType: vm, SumNodes: zoneNodes(req, len(zones), vm), VmClass: Spot, Role: Worker, Zone: zone}
+			if price := np.PoolPrice(); cheapest == nil || price < cheapestPrice {
+				cheapest, cheapestPrice = &np, price
+			}
+		}
+
+		if cheapest == nil {
+			warnings = append(warnings, fmt.Sprintf("no instance type with a spot price qualifies in zone %s", zone))
+			continue
+		}
+		nodePools[zone] = *cheapest
+	}
+
+	return nodePools, warnings, nil
+}
+
+// finishZoneNodePools converts and normalizes the prices of the zone node pools and sets their monthly costs
+func (e *Engine) finishZoneNodePools(nodePools map[string]NodePool, rate float64, includeRawPrices bool) {
+	for zone, np := range nodePools {
+		nps := []NodePool{np}
+		convertPrices(nps, rate)
+		normalizePrices(nps)
+		e.setMonthlyCosts(nps)
+		if !includeRawPrices {
+			removeZonePrices(nps)
+		}
+		nodePools[zone] = nps[0]
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright © 2019 Banzai Cloud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recommender
+
+import (
+	"testing"
+
+	"github.com/goph/emperror"
+	"github.com/goph/logur"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_RecommendClusterPerZone(t *testing.T) {
+	products := fixedProducts{
+		{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.08,
+			ZonePrices: map[string]float64{"eu-west-1a": 0.06, "eu-west-1b": 0.1, "eu-west-1c": 0.08}},
+		{Type: "m4.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.2, AvgPrice: 0.075,
+			ZonePrices: map[string]float64{"eu-west-1a": 0.07, "eu-west-1b": 0.07, "eu-west-1c": 0.085}},
+		{Type: "m5.2xlarge", Cpus: 8, Mem: 32, OnDemandPrice: 0.384, AvgPrice: 0.2,
+			ZonePrices: map[string]float64{"eu-west-1a": 0.2, "eu-west-1b": 0.2}},
+	}
+	tests := []struct {
+		name  string
+		req   ClusterRecommendationReq
+		check func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name: "no zone node pools by default",
+			req:  ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Nil(t, resp.PerZone)
+			},
+		},
+		{
+			name: "the cheapest type of each requested zone",
+			req:  ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2, Zones: []string{"eu-west-1a", "eu-west-1b"}, PerZone: true},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Len(t, resp.PerZone, 2)
+
+				zoneA := resp.PerZone["eu-west-1a"]
+				assert.Equal(t, "m5.xlarge", zoneA.VmType.Type)
+				assert.Equal(t, 0.06, zoneA.VmType.AvgPrice)
+				assert.Equal(t, "eu-west-1a", zoneA.Zone)
+				assert.Equal(t, Spot, zoneA.VmClass)
+				assert.Equal(t, 1, zoneA.SumNodes)
+				assert.Nil(t, zoneA.VmType.ZonePrices)
+
+				zoneB := resp.PerZone["eu-west-1b"]
+				assert.Equal(t, "m4.xlarge", zoneB.VmType.Type)
+				assert.Equal(t, 0.07, zoneB.VmType.AvgPrice)
+			},
+		},
+		{
+			name: "all zones with spot prices if none requested",
+			req:  ClusterRecommendationReq{SumCpu: 12, SumMem: 48, MinNodes: 1, MaxNodes: 3, PerZone: true},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Len(t, resp.PerZone, 3)
+				assert.Equal(t, "m5.xlarge", resp.PerZone["eu-west-1c"].VmType.Type)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &firstSpotPool{}, nil, EngineConfig{})
+			test.check(engine.RecommendCluster("dummy", "dummy", "dummy", test.req, nil))
+		})
+	}
+}
+
+func TestEngine_RecommendClusterPerZoneWithoutZonePrices(t *testing.T) {
+	products := fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.08}}
+	engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &firstSpotPool{}, nil, EngineConfig{})
+
+	resp, err := engine.RecommendCluster("dummy", "dummy", "dummy", ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2, PerZone: true}, nil)
+
+	assert.Nil(t, err, "the error should be nil")
+	assert.Empty(t, resp.PerZone)
+	assert.Contains(t, resp.Warnings, "no spot prices available per zone, no zone node pools are recommended")
+}
+
+func TestEngine_RecommendClusterPerZoneOnDemand(t *testing.T) {
+	tests := []struct {
+		name     string
+		products fixedProducts
+		req      ClusterRecommendationReq
+	}{
+		{
+			name:     "on-demand only requested",
+			products: fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.08, ZonePrices: map[string]float64{"eu-west-1a": 0.06}}},
+			req:      ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2, OnDemandPct: 100, PerZone: true},
+		},
+		{
+			name:     "spot information not used",
+			products: fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.08, ZonePrices: map[string]float64{"eu-west-1a": 0.06}}},
+			req:      ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2, OnDemandOnly: true, PerZone: true},
+		},
+		{
+			name:     "no spot prices in the region",
+			products: fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192}},
+			req:      ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2, OnDemandPct: 50, PerZone: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), test.products, &passThroughVms{}, &firstSpotPool{}, nil, EngineConfig{})
+
+			resp, err := engine.RecommendCluster("dummy", "dummy", "dummy", test.req, nil)
+
+			assert.Nil(t, err, "the error should be nil")
+			assert.Empty(t, resp.PerZone)
+			assert.Contains(t, resp.Warnings, "only on-demand nodes are recommended, no spot node pools per zone")
+		})
+	}
+}
+
+func TestEngine_RecommendClusterZoneLimits(t *testing.T) {
+	products := fixedProducts{{Type: "m5.xlarge", Cpus: 4, Mem: 16, OnDemandPrice: 0.192, AvgPrice: 0.08,
+		Zones: []string{"eu-west-1a", "eu-west-1b", "eu-west-1c"}}}
+	tooMany := make([]string, 0, 4)
+	for _, zone := range []string{"a", "b", "c", "d"} {
+		tooMany = append(tooMany, "eu-west-1"+zone)
+	}
+	tests := []struct {
+		name  string
+		zones []string
+		check func(resp *ClusterRecommendationResp, err error)
+	}{
+		{
+			name:  "duplicate zones are deduplicated",
+			zones: []string{"eu-west-1a", "eu-west-1b", "eu-west-1a", "eu-west-1b", "eu-west-1a"},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Equal(t, []string{"eu-west-1a", "eu-west-1b"}, resp.Zones)
+			},
+		},
+		{
+			name:  "duplicates don't count against the limit",
+			zones: append(append([]string(nil), tooMany[:3]...), tooMany[:3]...),
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.Nil(t, err, "the error should be nil")
+				assert.Len(t, resp.Zones, 3)
+			},
+		},
+		{
+			name:  "too many zones",
+			zones: tooMany,
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, "at most 3 zones can be requested, got 4")
+				assert.Contains(t, emperror.Context(err), RecommenderErrorTag)
+				assert.Nil(t, resp)
+			},
+		},
+		{
+			name:  "invalid zone",
+			zones: []string{"eu-west-1a", "eu-west-1a|.*"},
+			check: func(resp *ClusterRecommendationResp, err error) {
+				assert.EqualError(t, err, `invalid zone: "eu-west-1a|.*"`)
+				assert.Contains(t, emperror.Context(err), RecommenderErrorTag)
+				assert.Nil(t, resp)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := NewEngine(logur.NewTestLogger(), products, &passThroughVms{}, &firstSpotPool{}, nil, EngineConfig{MaxZones: 3})
+			req := ClusterRecommendationReq{SumCpu: 8, SumMem: 32, MinNodes: 1, MaxNodes: 2, Zones: test.zones}
+			test.check(engine.RecommendCluster("dummy", "dummy", "dummy", req, nil))
+		})
+	}
+}
+
+func Test_checkZones(t *testing.T) {
+	assert.Nil(t, checkZones(ClusterRecommendationReq{Zones: []string{"eu-west-1a", "europe-west1-b", "1"}}, DefaultMaxZones))
+	assert.EqualError(t, checkZones(ClusterRecommendationReq{PreferZones: []string{""}}, DefaultMaxZones), `invalid zone: ""`)
+	assert.EqualError(t, checkZones(ClusterRecommendationReq{Zones: []string{"eu-west-1a-"}}, DefaultMaxZones), `invalid zone: "eu-west-1a-"`)
+}